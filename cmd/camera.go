@@ -0,0 +1,231 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/EarthmanMuons/herosync/config"
+	"github.com/EarthmanMuons/herosync/internal/gopro"
+)
+
+// newCameraCmd constructs the "camera" subcommand.
+func newCameraCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "camera",
+		Short: "Control the GoPro remotely",
+		Long: `Control the GoPro remotely.
+
+Not every command is supported by every model; unsupported commands fail with
+an error naming the camera.`,
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:       "shutter start|stop",
+		Short:     "Start or stop recording",
+		Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		ValidArgs: []string{"start", "stop"},
+		RunE:      runCameraShutter,
+	})
+
+	presetCmd := &cobra.Command{
+		Use:   "preset",
+		Short: "Switch capture presets",
+	}
+	presetCmd.AddCommand(&cobra.Command{
+		Use:   "load ID|NAME",
+		Short: "Load a preset by ID, custom name, or mode (video, photo, timelapse)",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runCameraPresetLoad,
+	})
+	cmd.AddCommand(presetCmd)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "keepalive",
+		Short: "Keep the camera from going to sleep",
+		Args:  cobra.NoArgs,
+		RunE:  runCameraKeepAlive,
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "sleep",
+		Short: "Put the camera to sleep (legacy models only)",
+		Long: `Put the camera to sleep (legacy models only).
+
+The Open GoPro API has no sleep command, so this uses the legacy GoPro API.
+Cameras running Open GoPro (HERO9 and later) reject it with 404 Not Found; let
+them sleep on their own by not sending keep-alives instead.`,
+		Args: cobra.NoArgs,
+		RunE: runCameraSleep,
+	})
+
+	return cmd
+}
+
+// runCameraShutter is the entry point for the "camera shutter" subcommand.
+func runCameraShutter(cmd *cobra.Command, args []string) error {
+	ctx, _, cfg, err := contextLoggerConfig(cmd)
+	if err != nil {
+		return err
+	}
+	start := args[0] == "start"
+
+	return forEachCamera(cmd, cfg, func(logger *slog.Logger, cam config.Camera, client *gopro.Client) error {
+		state, err := client.GetCameraState(ctx)
+		if err != nil {
+			return err
+		}
+
+		if !start {
+			if !state.Status.Encoding {
+				logger.Info("camera isn't recording")
+				return nil
+			}
+			if isDryRun(cmd) {
+				printPlan("stop %s camera recording", cam)
+				return nil
+			}
+			if err := client.StopShutter(ctx); err != nil {
+				return err
+			}
+			logger.Info("recording stopped")
+			return nil
+		}
+
+		if state.Status.Encoding {
+			logger.Info("camera is already recording")
+			return nil
+		}
+		if err := checkIdle(state); err != nil {
+			return err
+		}
+		if isDryRun(cmd) {
+			printPlan("start %s camera recording", cam)
+			return nil
+		}
+		if err := client.StartShutter(ctx); err != nil {
+			return err
+		}
+		logger.Info("recording started")
+		return nil
+	})
+}
+
+// runCameraPresetLoad is the entry point for the "camera preset load" subcommand.
+func runCameraPresetLoad(cmd *cobra.Command, args []string) error {
+	ctx, _, cfg, err := contextLoggerConfig(cmd)
+	if err != nil {
+		return err
+	}
+	name := args[0]
+
+	return forEachCamera(cmd, cfg, func(logger *slog.Logger, cam config.Camera, client *gopro.Client) error {
+		state, err := client.GetCameraState(ctx)
+		if err != nil {
+			return err
+		}
+		if err := checkIdle(state); err != nil {
+			return err
+		}
+
+		load, err := presetLoader(ctx, client, name)
+		if err != nil {
+			return err
+		}
+
+		if isDryRun(cmd) {
+			printPlan("load %s camera preset %q", cam, name)
+			return nil
+		}
+		if err := load(); err != nil {
+			return err
+		}
+		logger.Info("preset loaded", slog.String("preset", name))
+		return nil
+	})
+}
+
+// presetLoader resolves a preset ID, mode name, or custom preset name to the
+// call that loads it.
+func presetLoader(ctx context.Context, client *gopro.Client, name string) (func() error, error) {
+	if id, err := strconv.Atoi(name); err == nil {
+		return func() error { return client.LoadPreset(ctx, id) }, nil
+	}
+
+	if group, ok := gopro.PresetGroups[strings.ToLower(name)]; ok {
+		return func() error { return client.LoadPresetGroup(ctx, group) }, nil
+	}
+
+	presets, err := client.GetPresets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	preset, ok := gopro.FindPreset(presets, name)
+	if !ok {
+		return nil, fmt.Errorf("no preset named %q (use an ID, a custom preset name, or video, photo, or timelapse)", name)
+	}
+	return func() error { return client.LoadPreset(ctx, preset.ID) }, nil
+}
+
+// runCameraKeepAlive is the entry point for the "camera keepalive" subcommand.
+func runCameraKeepAlive(cmd *cobra.Command, args []string) error {
+	ctx, _, cfg, err := contextLoggerConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	return forEachCamera(cmd, cfg, func(logger *slog.Logger, cam config.Camera, client *gopro.Client) error {
+		if isDryRun(cmd) {
+			printPlan("send keep-alive to %s camera", cam)
+			return nil
+		}
+		if err := client.KeepAlive(ctx); err != nil {
+			return err
+		}
+		logger.Debug("keep-alive sent")
+		return nil
+	})
+}
+
+// runCameraSleep is the entry point for the "camera sleep" subcommand.
+func runCameraSleep(cmd *cobra.Command, args []string) error {
+	ctx, _, cfg, err := contextLoggerConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	return forEachCamera(cmd, cfg, func(logger *slog.Logger, cam config.Camera, client *gopro.Client) error {
+		state, err := client.GetCameraState(ctx)
+		if err != nil {
+			return err
+		}
+		if state.Status.Encoding {
+			return fmt.Errorf("camera is recording; stop it first with \"herosync camera shutter stop\"")
+		}
+
+		if isDryRun(cmd) {
+			printPlan("put %s camera to sleep", cam)
+			return nil
+		}
+		if err := client.Sleep(ctx); err != nil {
+			return err
+		}
+		logger.Info("camera put to sleep")
+		return nil
+	})
+}
+
+// checkIdle returns an error if the camera is recording or busy.
+func checkIdle(state *gopro.CameraState) error {
+	switch {
+	case state.Status.Encoding:
+		return fmt.Errorf("camera is recording; stop it first with \"herosync camera shutter stop\"")
+	case state.Status.Busy:
+		return fmt.Errorf("camera is busy; try again in a moment")
+	default:
+		return nil
+	}
+}
@@ -6,11 +6,18 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 
+	"github.com/dustin/go-humanize"
 	"github.com/spf13/cobra"
 
+	"github.com/EarthmanMuons/herosync/config"
+	"github.com/EarthmanMuons/herosync/internal/fsutil"
 	"github.com/EarthmanMuons/herosync/internal/gopro"
 	"github.com/EarthmanMuons/herosync/internal/media"
+	"github.com/EarthmanMuons/herosync/internal/pubrecord"
+	"github.com/EarthmanMuons/herosync/internal/trash"
 )
 
 type cleanupOptions struct {
@@ -20,6 +27,9 @@ type cleanupOptions struct {
 	incomingDir string
 	remote      bool
 	local       bool
+	keepFree    string
+	dryRun      bool
+	trash       *trash.Bin
 }
 
 // newCleanupCmd constructs the "cleanup" subcommand.
@@ -36,20 +46,36 @@ By default, only files that have been successfully transferred to local storage
 USE FLAGS WITH CAUTION!
 
 * --remote deletes all GoPro files regardless of sync status.
-* --local deletes all local files in the "incoming" media subdirectory.
+* --local moves all local files in the "incoming" media subdirectory to the
+  trash, where they can be recovered with "herosync restore".
+* --outgoing deletes files in the "outgoing" media subdirectory whose
+  publication has been confirmed (see "publish --wait").
+* --keep-free deletes the oldest files that are already in sync from the GoPro
+  until the target amount of storage is free (e.g., "20%" or "32GB"). Files
+  that haven't been verified locally are never deleted.
 
 Combining --remote and --local will delete everything from both GoPro storage
 and local incoming media storage. The "outgoing" media subdirectory will remain
-untouched.
+untouched unless --outgoing is given; on its own, --outgoing leaves the GoPro
+and the "incoming" media subdirectory alone.
 
 If one or more [FILENAME] arguments are provided, only matching files will be
-affected.`,
+affected.
+
+When run interactively, --remote and --local ask for confirmation before
+deleting anything; pass --yes to skip the prompt.`,
 		Args: cobra.ArbitraryArgs,
 		RunE: runCleanup,
 	}
 
 	cmd.Flags().Bool("remote", false, "delete all files from GoPro storage")
-	cmd.Flags().Bool("local", false, "delete all files from local storage")
+	cmd.Flags().Bool("local", false, "move all files from local storage to the trash")
+	cmd.Flags().Bool("outgoing", false, "delete confirmed published files from outgoing storage")
+	cmd.Flags().String("keep-free", "", "delete oldest synced GoPro files until this much is free (e.g., 20%, 32GB)")
+	cmd.Flags().BoolP("yes", "y", false, "skip the confirmation prompt")
+
+	cmd.MarkFlagsMutuallyExclusive("keep-free", "remote")
+	cmd.MarkFlagsMutuallyExclusive("keep-free", "local")
 
 	return cmd
 }
@@ -61,30 +87,82 @@ func runCleanup(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	client, err := gopro.NewClient(logger, cfg.GoPro.Scheme, cfg.GoPro.Host)
-	if err != nil {
-		return err
+	remote, _ := cmd.Flags().GetBool("remote")
+	local, _ := cmd.Flags().GetBool("local")
+	outgoing, _ := cmd.Flags().GetBool("outgoing")
+	keepFree, _ := cmd.Flags().GetString("keep-free")
+	dryRun := isDryRun(cmd)
+
+	if outgoing {
+		if err := cleanupOutgoing(logger, cfg.OutgoingMediaDir(), args, dryRun); err != nil {
+			return err
+		}
+
+		// Only touch the GoPro when explicitly asked to alongside --outgoing.
+		if !remote && !local && keepFree == "" {
+			return nil
+		}
 	}
 
-	inventory, err := loadFilteredInventory(ctx, cfg, client, args)
+	bin, err := openTrash(logger, cfg, dryRun)
 	if err != nil {
 		return err
 	}
+	yes, _ := cmd.Flags().GetBool("yes")
 
-	incomingDir := cfg.IncomingMediaDir()
-	remote, _ := cmd.Flags().GetBool("remote")
-	local, _ := cmd.Flags().GetBool("local")
+	return forEachCamera(cmd, cfg, func(logger *slog.Logger, cam config.Camera, client *gopro.Client) error {
+		inventory, err := loadFilteredInventory(ctx, cfg, client, cam.IncomingDir, args)
+		if err != nil {
+			return err
+		}
 
-	opts := cleanupOptions{
-		logger:      logger,
-		client:      client,
-		inventory:   inventory,
-		incomingDir: incomingDir,
-		remote:      remote,
-		local:       local,
-	}
+		opts := cleanupOptions{
+			logger:      logger,
+			client:      client,
+			inventory:   inventory,
+			incomingDir: cam.IncomingDir,
+			remote:      remote,
+			local:       local,
+			keepFree:    keepFree,
+			dryRun:      dryRun,
+			trash:       bin,
+		}
+
+		// Deleting from the GoPro has to wait until it's done recording.
+		waitUntilReady := func() error {
+			if dryRun {
+				return nil
+			}
+			return ensureCameraReady(ctx, logger, cfg, client)
+		}
+
+		if keepFree != "" {
+			if err := waitUntilReady(); err != nil {
+				return err
+			}
+			return cleanupToKeepFree(ctx, &opts)
+		}
+
+		if (remote || local) && !yes && !dryRun && stdinIsTerminal() {
+			remoteCount, localCount := countCleanup(inventory, remote, local)
+			prompt := fmt.Sprintf("Delete %d file(s) from the GoPro and move %d local file(s) to the trash?", remoteCount, localCount)
+			if cam.Name != "" {
+				prompt = fmt.Sprintf("[%s] %s", cam.Name, prompt)
+			}
+			if !confirm(prompt) {
+				fmt.Println("Aborted.")
+				return nil
+			}
+		}
+
+		if remote {
+			if err := waitUntilReady(); err != nil {
+				return err
+			}
+		}
 
-	return cleanupInventory(ctx, &opts)
+		return cleanupInventory(ctx, &opts)
+	})
 }
 
 // cleanupInventory loops through the inventory and deletes applicable files.
@@ -107,6 +185,16 @@ func cleanupFile(ctx context.Context, file *media.File, opts *cleanupOptions) er
 	// Determine whether we should delete remote and/or local versions.
 	deleteRemote, deleteLocal := shouldCleanup(file, opts.remote, opts.local)
 
+	if opts.dryRun {
+		if deleteRemote {
+			printPlan("delete remote %s/%s", file.Directory, file.Filename)
+		}
+		if deleteLocal {
+			printPlan("move local %s to trash", filepath.Join(fsutil.ShortenPath(opts.incomingDir), file.Filename))
+		}
+		return nil
+	}
+
 	if deleteRemote {
 		remotePath := fmt.Sprintf("%s/%s", file.Directory, file.Filename)
 		opts.logger.Info("deleting remote file", slog.String("path", remotePath))
@@ -117,12 +205,12 @@ func cleanupFile(ctx context.Context, file *media.File, opts *cleanupOptions) er
 
 	if deleteLocal {
 		localPath := filepath.Join(opts.incomingDir, file.Filename)
-		opts.logger.Info("deleting local file", slog.String("path", localPath))
-		if err := os.Remove(localPath); err != nil {
+		opts.logger.Info("moving local file to trash", slog.String("path", localPath))
+		if _, err := opts.trash.Move(localPath, "cleanup"); err != nil {
 			if os.IsNotExist(err) {
 				opts.logger.Warn("local file does not exist", slog.String("path", localPath))
 			} else {
-				opts.logger.Error("failed to delete local file", slog.String("path", localPath), slog.Any("error", err))
+				opts.logger.Error("failed to trash local file", slog.String("path", localPath), slog.Any("error", err))
 			}
 		}
 	}
@@ -130,6 +218,158 @@ func cleanupFile(ctx context.Context, file *media.File, opts *cleanupOptions) er
 	return nil
 }
 
+// countCleanup returns how many remote and local files the cleanup would delete.
+func countCleanup(inventory *media.Inventory, remote, local bool) (remoteCount, localCount int) {
+	for _, file := range inventory.Files {
+		if file.Status == media.Processed {
+			continue
+		}
+		deleteRemote, deleteLocal := shouldCleanup(&file, remote, local)
+		if deleteRemote {
+			remoteCount++
+		}
+		if deleteLocal {
+			localCount++
+		}
+	}
+	return remoteCount, localCount
+}
+
+// cleanupToKeepFree deletes the oldest files that are already in sync from the
+// GoPro until the target amount of free storage is reached.
+func cleanupToKeepFree(ctx context.Context, opts *cleanupOptions) error {
+	cs, err := opts.client.GetCameraState(ctx)
+	if err != nil {
+		return err
+	}
+
+	capacity := cs.Status.SDCardCapacity
+	if capacity <= 0 {
+		return fmt.Errorf("no storage detected on GoPro")
+	}
+
+	target, err := parseFreeSpaceTarget(opts.keepFree, capacity)
+	if err != nil {
+		return err
+	}
+
+	free := cs.Status.SDCardRemaining
+	if free >= target {
+		opts.logger.Info("enough free space on GoPro",
+			slog.String("free", humanize.Bytes(uint64(free))),
+			slog.String("target", humanize.Bytes(uint64(target))),
+		)
+		return nil
+	}
+
+	// The inventory is sorted by creation time, so the oldest files go first.
+	for _, file := range opts.inventory.Files {
+		if free >= target {
+			break
+		}
+
+		// Never delete files that haven't been verified locally.
+		if file.Status != media.InSync {
+			continue
+		}
+
+		remotePath := fmt.Sprintf("%s/%s", file.Directory, file.Filename)
+		if opts.dryRun {
+			printPlan("delete remote %s (%s)", remotePath, humanize.Bytes(uint64(file.Size)))
+			free += file.Size
+			continue
+		}
+
+		opts.logger.Info("deleting remote file", slog.String("path", remotePath))
+		if err := opts.client.DeleteSingleMediaFile(ctx, remotePath); err != nil {
+			opts.logger.Error("failed to delete remote file", slog.String("path", remotePath), slog.Any("error", err))
+			continue
+		}
+		free += file.Size
+	}
+
+	if free < target {
+		opts.logger.Warn("target free space not reached; remaining files are not yet in sync",
+			slog.String("free", humanize.Bytes(uint64(free))),
+			slog.String("target", humanize.Bytes(uint64(target))),
+		)
+	}
+
+	return nil
+}
+
+// parseFreeSpaceTarget converts a free space target, given either as a
+// percentage of capacity (e.g., "20%") or a byte size (e.g., "32GB"), to bytes.
+func parseFreeSpaceTarget(target string, capacity int64) (int64, error) {
+	target = strings.TrimSpace(target)
+
+	if pct, ok := strings.CutSuffix(target, "%"); ok {
+		percent, err := strconv.ParseFloat(strings.TrimSpace(pct), 64)
+		if err != nil || percent < 0 || percent > 100 {
+			return 0, fmt.Errorf("invalid free space percentage: %q", target)
+		}
+		return int64(float64(capacity) * percent / 100), nil
+	}
+
+	size, err := humanize.ParseBytes(target)
+	if err != nil {
+		return 0, fmt.Errorf("invalid free space target: %q (use a percentage or size)", target)
+	}
+	return int64(size), nil
+}
+
+// cleanupOutgoing deletes outgoing files whose publication has been confirmed.
+// If keywords are provided, only filenames containing one of them are affected.
+func cleanupOutgoing(logger *slog.Logger, outgoingDir string, keywords []string, dryRun bool) error {
+	record, err := pubrecord.Load(defaultPublicationRecordPath())
+	if err != nil {
+		return err
+	}
+
+	entries, err := confirmedOutgoingFiles(outgoingDir, record, "")
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if !matchesAnyKeyword(entry.Filename, keywords) {
+			continue
+		}
+
+		path := filepath.Join(outgoingDir, entry.Filename)
+		if dryRun {
+			printPlan("delete published %s (video %s)", fsutil.ShortenPath(path), entry.VideoID)
+			continue
+		}
+
+		logger.Info("deleting published file", slog.String("path", path), slog.String("video-id", entry.VideoID))
+		if err := os.Remove(path); err != nil {
+			logger.Error("failed to delete published file", slog.String("path", path), slog.Any("error", err))
+			continue
+		}
+		if err := removeCompanionFiles(path); err != nil {
+			logger.Warn("failed to delete sidecars", slog.String("path", path), slog.Any("error", err))
+		}
+	}
+
+	return nil
+}
+
+// matchesAnyKeyword reports whether s contains any of the keywords
+// (case-insensitive). An empty keyword list matches everything.
+func matchesAnyKeyword(s string, keywords []string) bool {
+	if len(keywords) == 0 {
+		return true
+	}
+	haystack := strings.ToLower(s)
+	for _, needle := range keywords {
+		if strings.Contains(haystack, strings.ToLower(needle)) {
+			return true
+		}
+	}
+	return false
+}
+
 // shouldCleanup determines whether a file should be deleted based on the flags.
 func shouldCleanup(file *media.File, remote, local bool) (deleteRemote bool, deleteLocal bool) {
 	if file.Status == media.InSync {
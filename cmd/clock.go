@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/EarthmanMuons/herosync/config"
+	"github.com/EarthmanMuons/herosync/internal/gopro"
+)
+
+// newClockCmd constructs the "clock" subcommand.
+func newClockCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "clock",
+		Short: "Check or set the GoPro's clock",
+		Long: `Check or set the GoPro's clock.
+
+Media timestamps, date grouping, and YouTube recording dates all come from the
+camera's clock, which can drift over time or be reset after the battery is
+removed. Syncing only affects media recorded afterwards.`,
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "show",
+		Short: "Compare the GoPro's clock with this computer's clock",
+		Args:  cobra.NoArgs,
+		RunE:  runClockShow,
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "sync",
+		Short: "Set the GoPro's clock from this computer's clock",
+		Args:  cobra.NoArgs,
+		RunE:  runClockSync,
+	})
+
+	return cmd
+}
+
+// runClockShow is the entry point for the "clock show" subcommand.
+func runClockShow(cmd *cobra.Command, args []string) error {
+	ctx, _, cfg, err := contextLoggerConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	return forEachCamera(cmd, cfg, func(logger *slog.Logger, cam config.Camera, client *gopro.Client) error {
+		drift, err := client.ClockDrift(ctx)
+		if err != nil {
+			return err
+		}
+
+		if cam.Name != "" {
+			fmt.Printf("== %s ==\n", cam.Name)
+		}
+		now := time.Now()
+		fmt.Printf("Camera: %s\n", now.Add(drift).Format(time.DateTime))
+		fmt.Printf("Host:   %s\n", now.Format(time.DateTime))
+		fmt.Printf("Drift:  %s\n", formatClockDrift(drift, cfg.Clock.MaxDrift))
+
+		return nil
+	})
+}
+
+// runClockSync is the entry point for the "clock sync" subcommand.
+func runClockSync(cmd *cobra.Command, args []string) error {
+	ctx, _, cfg, err := contextLoggerConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	return forEachCamera(cmd, cfg, func(logger *slog.Logger, cam config.Camera, client *gopro.Client) error {
+		if isDryRun(cmd) {
+			printPlan("set %s camera clock to %s", cam, time.Now().Format(time.DateTime))
+			return nil
+		}
+
+		return syncClock(ctx, logger, client)
+	})
+}
+
+// syncClock sets the camera's clock to the host's current time.
+func syncClock(ctx context.Context, logger *slog.Logger, client *gopro.Client) error {
+	now := time.Now()
+	if err := client.SetDateTime(ctx, now); err != nil {
+		return err
+	}
+
+	logger.Info("camera clock synchronized", slog.String("time", now.Format(time.DateTime)))
+	return nil
+}
+
+// syncClockIfDrifted syncs the camera's clock when it differs from the host's
+// clock by more than the configured maximum drift.
+func syncClockIfDrifted(ctx context.Context, logger *slog.Logger, cfg *config.Config, client *gopro.Client, dryRun bool) error {
+	drift, err := client.ClockDrift(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !clockDrifted(drift, cfg.Clock.MaxDrift) {
+		logger.Debug("camera clock within tolerance", slog.Duration("drift", drift))
+		return nil
+	}
+
+	if dryRun {
+		printPlan("set camera clock to %s (drift %s)", time.Now().Format(time.DateTime), drift)
+		return nil
+	}
+
+	return syncClock(ctx, logger, client)
+}
+
+// clockDrifted reports whether the drift exceeds the maximum in either direction.
+func clockDrifted(drift, maxDrift time.Duration) bool {
+	return drift.Abs() > maxDrift
+}
+
+func formatClockDrift(drift, maxDrift time.Duration) string {
+	if !clockDrifted(drift, maxDrift) {
+		return fmt.Sprintf("in sync (%s)", drift)
+	}
+
+	direction := "ahead of"
+	if drift < 0 {
+		direction = "behind"
+	}
+	return fmt.Sprintf("camera is %s %s this computer (run \"herosync clock sync\")", drift.Abs(), direction)
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/spf13/cobra"
+
+	"github.com/EarthmanMuons/herosync/config"
+	"github.com/EarthmanMuons/herosync/internal/fsutil"
+	"github.com/EarthmanMuons/herosync/internal/gopro"
+)
+
+// newCOHNCmd constructs the "cohn" subcommand.
+func newCOHNCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cohn",
+		Short: "Manage Camera on the Home Network (COHN) access",
+		Long: `Manage Camera on the Home Network (COHN) access.
+
+With COHN, the GoPro serves its API over HTTPS using a certificate signed by its
+own root CA, and requires the username and password shown in the GoPro Quik
+app. Configure them with:
+
+  [gopro]   # or [cameras.NAME]
+  scheme = "https"
+  username = "gopro"
+  password = "..."
+  ca-cert = "/path/to/cohn.crt"   # or: cert-fingerprint = "AB:CD:..."`,
+	}
+
+	fetchCmd := &cobra.Command{
+		Use:   "fetch-cert",
+		Short: "Save the GoPro's COHN certificate for use as ca-cert",
+		Long: `Save the GoPro's COHN certificate for use as ca-cert.
+
+The certificate is fetched without verification, so only run this on a network
+you trust. Its SHA-256 fingerprint is printed for use as cert-fingerprint.`,
+		Args: cobra.NoArgs,
+		RunE: runCOHNFetchCert,
+	}
+	fetchCmd.Flags().StringP("output", "o", "", "file to save the certificate to [default: cohn.crt in the config directory]")
+	fetchCmd.MarkFlagFilename("output", "crt", "pem")
+	cmd.AddCommand(fetchCmd)
+
+	return cmd
+}
+
+// runCOHNFetchCert is the entry point for the "cohn fetch-cert" subcommand.
+func runCOHNFetchCert(cmd *cobra.Command, args []string) error {
+	ctx, logger, cfg, err := contextLoggerConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	cameras, err := selectedCameras(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	output, _ := cmd.Flags().GetString("output")
+	if output != "" && len(cameras) > 1 {
+		return fmt.Errorf("--output needs a single camera; select one with --camera")
+	}
+
+	for _, cam := range cameras {
+		path := output
+		if path == "" {
+			path = defaultCOHNCertPath(cam)
+		}
+
+		var certs []*x509.Certificate
+		if cam.Host == "" && cam.Serial != "" {
+			var opts []gopro.Option
+			if cam.Username != "" {
+				opts = append(opts, gopro.WithBasicAuth(cam.Username, cam.Password))
+			}
+			certs, err = gopro.FetchCertificatesForSerial(ctx, logger, cam.Serial, opts...)
+		} else {
+			certs, err = gopro.FetchCertificates(ctx, cam.Host)
+		}
+		if err != nil {
+			return fmt.Errorf("camera %s: %w", cam, err)
+		}
+
+		// Prefer the camera's root CA, which outlives its server certificate.
+		cert := certs[len(certs)-1]
+
+		if isDryRun(cmd) {
+			printPlan("save %s certificate %q to %s", cam, cert.Subject.CommonName, fsutil.ShortenPath(path))
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			return fmt.Errorf("creating directory: %w", err)
+		}
+		data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return fmt.Errorf("writing certificate: %w", err)
+		}
+
+		logger.Info("saved camera certificate", slog.String("camera", cam.String()), slog.String("path", fsutil.ShortenPath(path)))
+		fmt.Printf("Subject: %s\n", cert.Subject.CommonName)
+		fmt.Printf("Expires: %s\n", cert.NotAfter.Local().Format(time.DateOnly))
+		fmt.Printf("SHA-256 Fingerprint: %s\n", gopro.Fingerprint(cert))
+	}
+
+	return nil
+}
+
+// defaultCOHNCertPath returns where the camera's certificate is saved by default.
+func defaultCOHNCertPath(cam config.Camera) string {
+	name := "cohn.crt"
+	if cam.Name != "" {
+		name = fmt.Sprintf("cohn-%s.crt", cam.Name)
+	}
+	return filepath.Join(xdg.ConfigHome, "herosync", name)
+}
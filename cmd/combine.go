@@ -2,29 +2,46 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/EarthmanMuons/herosync/config"
 	"github.com/EarthmanMuons/herosync/internal/fsutil"
 	"github.com/EarthmanMuons/herosync/internal/gopro"
+	"github.com/EarthmanMuons/herosync/internal/gpmf"
 	"github.com/EarthmanMuons/herosync/internal/media"
+	"github.com/EarthmanMuons/herosync/internal/overlay"
+	"github.com/EarthmanMuons/herosync/internal/sidecar"
+	"github.com/EarthmanMuons/herosync/internal/trash"
 )
 
 type combineOptions struct {
-	logger       *slog.Logger
-	client       *gopro.Client
-	inventory    *media.Inventory
-	incomingDir  string
-	outgoingDir  string
-	groupBy      GroupBy
-	keepOriginal bool
+	logger         *slog.Logger
+	cfg            *config.Config
+	client         *gopro.Client
+	inventory      *media.Inventory
+	incomingDir    string
+	outgoingDir    string
+	incomingIngest *media.IngestLog
+	outgoingIngest *media.IngestLog
+	groupBy        GroupBy
+	keepOriginal   bool
+	dryRun         bool
+	trash          *trash.Bin
+	preserveTracks bool
+	overlay        bool
+	gauges         []overlay.Gauge
+	camera         *gopro.HardwareInfo
 }
 
 // GroupBy defines the type for grouping files.
@@ -46,7 +63,10 @@ func newCombineCmd() *cobra.Command {
 	}
 
 	cmd.Flags().String("group-by", "", "group videos by (chapters, date)")
-	cmd.Flags().BoolP("keep-original", "k", false, "prevent deleting original files after combining")
+	cmd.Flags().BoolP("keep-original", "k", false, "prevent moving original files to the trash after combining")
+	cmd.Flags().Bool("preserve-tracks", false, "keep all streams, including GPMF telemetry and timecode tracks")
+	cmd.Flags().Bool("overlay", false, "burn a telemetry overlay into the combined video (re-encodes)")
+	cmd.Flags().StringSlice("gauges", nil, "overlay gauges (speed, altitude, time, map) [default: from config]")
 
 	return cmd
 }
@@ -58,42 +78,92 @@ func runCombine(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	client, err := gopro.NewClient(logger, cfg.GoPro.Scheme, cfg.GoPro.Host)
-	if err != nil {
+	// Apply retention first so the inventory reflects what remains on disk.
+	if err := enforceRetention(cmd, logger, cfg); err != nil {
 		return err
 	}
 
-	inventory, err := loadFilteredInventory(ctx, cfg, client, args)
+	outgoingDir := cfg.OutgoingMediaDir()
+	groupBy, err := ParseGroupBy(cfg.Group.By)
 	if err != nil {
 		return err
 	}
+	keepOriginal, _ := cmd.Flags().GetBool("keep-original")
 
-	incomingDir := cfg.IncomingMediaDir()
-	outgoingDir := cfg.OutgoingMediaDir()
-	groupBy, err := ParseGroupBy(cfg.Group.By)
+	preserveTracks := cfg.Combine.PreserveTracks
+	if cmd.Flags().Changed("preserve-tracks") {
+		preserveTracks, _ = cmd.Flags().GetBool("preserve-tracks")
+	}
+
+	burnOverlay := cfg.Combine.Overlay
+	if cmd.Flags().Changed("overlay") {
+		burnOverlay, _ = cmd.Flags().GetBool("overlay")
+	}
+
+	gaugeNames := cfg.OverlayGauges()
+	if cmd.Flags().Changed("gauges") {
+		gaugeNames, _ = cmd.Flags().GetStringSlice("gauges")
+	}
+	gauges, err := overlay.ParseGauges(gaugeNames)
 	if err != nil {
 		return err
 	}
-	keepOriginal, _ := cmd.Flags().GetBool("keep-original")
 
-	opts := combineOptions{
-		logger:       logger,
-		client:       client,
-		inventory:    inventory,
-		incomingDir:  incomingDir,
-		outgoingDir:  outgoingDir,
-		groupBy:      groupBy,
-		keepOriginal: keepOriginal,
+	bin, err := openTrash(logger, cfg, isDryRun(cmd))
+	if err != nil {
+		return err
 	}
 
-	switch groupBy {
-	case GroupByChapters:
-		return combineByChapters(ctx, &opts)
-	case GroupByDate:
-		return combineByDate(ctx, &opts)
-	default:
-		return fmt.Errorf("invalid grouping: %s", groupBy)
+	outgoingIngest, err := media.LoadIngestLog(outgoingDir)
+	if err != nil {
+		return err
 	}
+
+	return forEachCamera(cmd, cfg, func(logger *slog.Logger, cam config.Camera, client *gopro.Client) error {
+		inventory, err := loadFilteredInventory(ctx, cfg, client, cam.IncomingDir, args)
+		if err != nil {
+			return err
+		}
+
+		incomingIngest, err := media.LoadIngestLog(cam.IncomingDir)
+		if err != nil {
+			return err
+		}
+
+		// Camera details are only used to tag the output, so they're optional.
+		camera, err := client.GetHardwareInfo(ctx)
+		if err != nil {
+			logger.Warn("failed to get camera info", slog.Any("error", err))
+		}
+
+		opts := combineOptions{
+			logger:         logger,
+			cfg:            cfg,
+			client:         client,
+			inventory:      inventory,
+			incomingDir:    cam.IncomingDir,
+			outgoingDir:    outgoingDir,
+			incomingIngest: incomingIngest,
+			outgoingIngest: outgoingIngest,
+			groupBy:        groupBy,
+			keepOriginal:   keepOriginal,
+			dryRun:         isDryRun(cmd),
+			trash:          bin,
+			preserveTracks: preserveTracks,
+			overlay:        burnOverlay,
+			gauges:         gauges,
+			camera:         camera,
+		}
+
+		switch groupBy {
+		case GroupByChapters:
+			return combineByChapters(ctx, &opts)
+		case GroupByDate:
+			return combineByDate(ctx, &opts)
+		default:
+			return fmt.Errorf("invalid grouping: %s", groupBy)
+		}
+	})
 }
 
 func combineByChapters(ctx context.Context, opts *combineOptions) error {
@@ -142,10 +212,23 @@ func combineByDate(ctx context.Context, opts *combineOptions) error {
 
 func combineFiles(ctx context.Context, inv *media.Inventory, opts *combineOptions) error {
 	if inv.HasUnsyncedFiles() {
+		if isRetired(inv, opts.incomingIngest) {
+			opts.logger.Debug("skipping group; already combined and removed by retention")
+			return nil
+		}
 		opts.logger.Warn("skipping group; not all files have been downloaded")
 		return nil
 	}
 
+	if opts.dryRun {
+		return planCombine(inv, opts)
+	}
+
+	// The combined output needs about as much space as its inputs.
+	if err := ensureFreeSpace(opts.logger, opts.cfg, opts.outgoingDir, inv.TotalSize(), opts.dryRun); err != nil {
+		return err
+	}
+
 	inputFiles, err := buildFFmpegInputList(inv, opts.incomingDir)
 	if err != nil {
 		return err
@@ -157,9 +240,27 @@ func combineFiles(ctx context.Context, inv *media.Inventory, opts *combineOption
 	}
 	fmt.Printf("Output file: %s\n", fsutil.ShortenPath(outputPath))
 
-	if err := runFFmpegWithInputList(ctx, inputFiles, outputPath, opts); err != nil {
+	// Probe the first input so that every one of its streams can be mapped.
+	var streams []media.Stream
+	if opts.preserveTracks {
+		streams, err = media.ProbeStreams(ctx, filepath.Join(opts.incomingDir, inv.Files[0].Filename))
+		if err != nil {
+			return err
+		}
+	}
+
+	outputArgs := append(trackMappingArgs(streams), metadataArgs(inv, outputPath, opts.camera)...)
+	command, err := runFFmpegWithInputList(ctx, inputFiles, outputPath, outputArgs, opts)
+	if err != nil {
 		return err
 	}
+	commands := []string{command}
+
+	if opts.preserveTracks {
+		if err := verifyTracks(ctx, streams, outputPath); err != nil {
+			return fmt.Errorf("failed to verify combined file: %w", err)
+		}
+	}
 
 	// Preserve the modification time from the first video.
 	if err := fsutil.SetMtime(opts.logger, outputPath, inv.Files[0].CreatedAt); err != nil {
@@ -171,21 +272,269 @@ func combineFiles(ctx context.Context, inv *media.Inventory, opts *combineOption
 		return fmt.Errorf("failed to verify combined file: %w", err)
 	}
 
-	// Delete the original files if --keep-original is not set.
+	// A failed overlay still leaves a usable combined video behind.
+	if opts.overlay {
+		command, err := renderOverlay(ctx, inv, outputPath, opts)
+		if err != nil {
+			opts.logger.Warn("failed to render overlay", slog.String("output", filepath.Base(outputPath)), slog.Any("error", err))
+		} else {
+			commands = append(commands, command)
+			if err := fsutil.SetMtime(opts.logger, outputPath, inv.Files[0].CreatedAt); err != nil {
+				return err
+			}
+		}
+	}
+
+	// The sidecar only adds detail, so a failure doesn't stop the originals
+	// from being cleaned up.
+	if err := writeSidecar(ctx, inv, outputPath, commands, opts); err != nil {
+		opts.logger.Warn("failed to write sidecar", slog.String("output", filepath.Base(outputPath)), slog.Any("error", err))
+	}
+
+	// Export telemetry from the chapters, which carry their own creation times.
+	if opts.cfg.Telemetry.OnCombine {
+		exportGroupTelemetry(inv, outputPath, opts)
+	}
+
+	// Retention may delete the originals from now on, and ages the combined
+	// video from its creation.
+	var filenames []string
+	for _, file := range inv.Files {
+		filenames = append(filenames, file.Filename)
+	}
+	if err := opts.incomingIngest.MarkCombined(filenames...); err != nil {
+		return err
+	}
+	if err := opts.outgoingIngest.MarkIngested(filepath.Base(outputPath)); err != nil {
+		return err
+	}
+
+	// Move the original files to the trash if --keep-original is not set.
 	if !opts.keepOriginal {
+		reason := fmt.Sprintf("combined into %s", filepath.Base(outputPath))
 		for _, file := range inv.Files {
 			path := filepath.Join(opts.incomingDir, file.Filename)
-			if err := os.Remove(path); err != nil {
-				opts.logger.Error("failed to delete local file", slog.String("path", path), slog.Any("error", err))
+			if _, err := opts.trash.Move(path, reason); err != nil {
+				opts.logger.Error("failed to trash local file", slog.String("path", path), slog.Any("error", err))
 				return err
 			}
-			opts.logger.Info("local file deleted", slog.String("filename", file.Filename))
+			opts.logger.Info("local file moved to trash", slog.String("filename", file.Filename))
+		}
+	}
+
+	return nil
+}
+
+// isRetired reports whether every file of the group was deleted locally by a
+// retention policy, having already been combined.
+func isRetired(inv *media.Inventory, ingest *media.IngestLog) bool {
+	for _, file := range inv.Files {
+		entry, ok := ingest.Get(file.Filename)
+		if !ok || !entry.Retired(file.Size) {
+			return false
+		}
+	}
+	return true
+}
+
+// planCombine prints how a group of files would be combined without running FFmpeg.
+func planCombine(inv *media.Inventory, opts *combineOptions) error {
+	outputPath, err := generateOutputPath(inv, opts.groupBy, opts.outgoingDir)
+	if err != nil {
+		return err
+	}
+
+	var filenames []string
+	for _, file := range inv.Files {
+		filenames = append(filenames, file.Filename)
+	}
+	printPlan("merge %s into %s", strings.Join(filenames, ", "), fsutil.ShortenPath(outputPath))
+
+	if opts.overlay {
+		var names []string
+		for _, g := range opts.gauges {
+			names = append(names, string(g))
+		}
+		printPlan("burn %s overlay into %s", strings.Join(names, ", "), fsutil.ShortenPath(outputPath))
+	}
+
+	printPlan("write metadata to %s", fsutil.ShortenPath(sidecar.Path(outputPath)))
+
+	if !opts.keepOriginal {
+		for _, filename := range filenames {
+			printPlan("move local %s to trash after merging", filepath.Join(fsutil.ShortenPath(opts.incomingDir), filename))
 		}
 	}
 
 	return nil
 }
 
+// exportGroupTelemetry writes telemetry sidecars for a combined video from the
+// files it was built from. Failures are logged rather than returned, since the
+// combined video itself is still usable.
+func exportGroupTelemetry(inv *media.Inventory, outputPath string, opts *combineOptions) {
+	t, err := extractTelemetry(inv, opts.incomingDir)
+	if err != nil {
+		opts.logger.Warn("failed to extract telemetry", slog.String("output", filepath.Base(outputPath)), slog.Any("error", err))
+		return
+	}
+
+	written, err := writeTelemetrySidecars(outputPath, t, opts.cfg.TelemetryFormats())
+	if err != nil {
+		opts.logger.Warn("failed to export telemetry", slog.String("output", filepath.Base(outputPath)), slog.Any("error", err))
+		return
+	}
+
+	for _, sidecar := range written {
+		opts.logger.Info("telemetry exported", slog.String("path", fsutil.ShortenPath(sidecar)))
+	}
+}
+
+// renderOverlay burns the telemetry gauges into the combined video at
+// outputPath, re-encoding its video stream. It returns the FFmpeg command run.
+func renderOverlay(ctx context.Context, inv *media.Inventory, outputPath string, opts *combineOptions) (string, error) {
+	samples, err := overlaySamples(ctx, inv, opts.incomingDir)
+	if err != nil {
+		return "", err
+	}
+	if len(samples) == 0 {
+		return "", fmt.Errorf("no GPS samples: %w", gpmf.ErrNoTelemetry)
+	}
+
+	streams, err := media.ProbeStreams(ctx, outputPath)
+	if err != nil {
+		return "", err
+	}
+	video := slices.IndexFunc(streams, func(s media.Stream) bool { return s.CodecType == "video" })
+	if video < 0 {
+		return "", fmt.Errorf("no video stream in %s", filepath.Base(outputPath))
+	}
+
+	duration, err := media.ProbeDuration(ctx, outputPath)
+	if err != nil {
+		return "", err
+	}
+
+	script, err := os.CreateTemp("", "overlay*.ass")
+	if err != nil {
+		return "", fmt.Errorf("creating temp file: %w", err)
+	}
+	defer os.Remove(script.Name())
+	defer script.Close()
+
+	err = overlay.WriteASS(script, samples, duration, overlay.Options{
+		Gauges:   opts.gauges,
+		Width:    streams[video].Width,
+		Height:   streams[video].Height,
+		Imperial: opts.cfg.Overlay.Units == "imperial",
+	})
+	if err != nil {
+		return "", fmt.Errorf("writing overlay script: %w", err)
+	}
+
+	// Re-encoding needs room for a second copy of the video.
+	if err := ensureFreeSpace(opts.logger, opts.cfg, opts.outgoingDir, inv.TotalSize(), opts.dryRun); err != nil {
+		return "", err
+	}
+
+	ext := filepath.Ext(outputPath)
+	tmpPath := strings.TrimSuffix(outputPath, ext) + ".overlay" + ext
+
+	args := []string{"-y", "-i", outputPath}
+	if opts.preserveTracks {
+		args = append(args, trackMappingArgs(streams)...)
+	}
+	// Temp file paths contain no quotes, so quoting is enough to escape them
+	// within the filter graph. Only the filtered video is re-encoded, with the
+	// configured encoder; audio and data tracks are copied as they are.
+	args = append(args, "-vf", fmt.Sprintf("ass=filename='%s'", script.Name()), "-movflags", "+use_metadata_tags", "-c:a", "copy", "-c:d", "copy")
+	args = append(args, strings.Fields(opts.cfg.Overlay.EncoderArgs)...)
+	args = append(args, tmpPath)
+
+	fmt.Printf("Rendering overlay: %s\n", fsutil.ShortenPath(outputPath))
+	if err := execFFmpeg(ctx, args, opts.logger); err != nil {
+		os.Remove(tmpPath)
+		return "", err
+	}
+
+	if err := os.Rename(tmpPath, outputPath); err != nil {
+		return "", fmt.Errorf("replacing combined video: %w", err)
+	}
+	return formatCommand("ffmpeg", args), nil
+}
+
+// overlaySamples reads the GPS samples of each file in a group and places them
+// on the timeline of the combined video, where the files play back to back.
+func overlaySamples(ctx context.Context, inv *media.Inventory, dir string) ([]overlay.Sample, error) {
+	var samples []overlay.Sample
+	var offset time.Duration
+
+	for _, file := range inv.Files {
+		path := filepath.Join(dir, file.Filename)
+
+		t, err := gpmf.Extract(path, file.CreatedAt)
+		if err != nil && !errors.Is(err, gpmf.ErrNoTelemetry) {
+			return nil, fmt.Errorf("extracting telemetry from %s: %w", file.Filename, err)
+		}
+		if t != nil {
+			for _, s := range t.GPS {
+				samples = append(samples, overlay.Sample{Offset: offset + s.Time.Sub(file.CreatedAt), GPSSample: s})
+			}
+		}
+
+		duration, err := media.ProbeDuration(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		offset += duration
+	}
+
+	return samples, nil
+}
+
+// writeSidecar records how the combined video at outputPath was made.
+func writeSidecar(ctx context.Context, inv *media.Inventory, outputPath string, commands []string, opts *combineOptions) error {
+	s := &sidecar.Sidecar{
+		Video:      filepath.Base(outputPath),
+		CreatedAt:  inv.Files[0].CreatedAt,
+		CombinedAt: time.Now(),
+		GroupBy:    opts.groupBy.String(),
+		FFmpeg:     sidecar.FFmpeg{Commands: commands},
+	}
+
+	for _, file := range inv.Files {
+		info := gopro.ParseFilename(file.Filename)
+		s.Sources = append(s.Sources, sidecar.Source{
+			Filename:  file.Filename,
+			MediaID:   info.MediaID,
+			Chapter:   info.Chapter,
+			Size:      file.Size,
+			CreatedAt: file.CreatedAt,
+		})
+	}
+
+	if opts.camera != nil {
+		s.Camera = &sidecar.Camera{
+			Model:    opts.camera.ModelName,
+			Serial:   opts.camera.SerialNumber,
+			Firmware: opts.camera.FirmwareVersion,
+		}
+	}
+
+	version, err := media.FFmpegVersion(ctx)
+	if err != nil {
+		opts.logger.Debug("failed to get ffmpeg version", slog.Any("error", err))
+	}
+	s.FFmpeg.Version = version
+
+	if err := sidecar.Write(outputPath, s); err != nil {
+		return err
+	}
+
+	opts.logger.Debug("sidecar written", slog.String("path", fsutil.ShortenPath(sidecar.Path(outputPath))))
+	return nil
+}
+
 // buildFFmpegInputList builds the list of input files for FFmpeg and calculates total size.
 func buildFFmpegInputList(inv *media.Inventory, mediaDir string) ([]string, error) {
 	var inputFiles []string
@@ -215,42 +564,64 @@ func generateOutputPath(inv *media.Inventory, groupBy GroupBy, mediaDir string)
 	return fsutil.GenerateUniqueFilename(fullPath)
 }
 
-// runFFmpegWithInputList creates a temp file list, and executes FFmpeg.
-func runFFmpegWithInputList(ctx context.Context, inputFiles []string, outputFilePath string, opts *combineOptions) error {
+// runFFmpegWithInputList creates a temp file list, and executes FFmpeg. It
+// returns the FFmpeg command run.
+func runFFmpegWithInputList(ctx context.Context, inputFiles []string, outputFilePath string, outputArgs []string, opts *combineOptions) (string, error) {
 	// Ensure the output directory exists before running FFmpeg.
 	if err := os.MkdirAll(filepath.Dir(outputFilePath), 0o750); err != nil {
-		return fmt.Errorf("creating output directory: %w", err)
+		return "", fmt.Errorf("creating output directory: %w", err)
 	}
 
 	tmpFile, err := os.CreateTemp("", "filelist*.txt")
 	if err != nil {
-		return fmt.Errorf("creating temp file: %w", err)
+		return "", fmt.Errorf("creating temp file: %w", err)
 	}
 	defer os.Remove(tmpFile.Name())
 	defer tmpFile.Close()
 
 	if _, err := tmpFile.WriteString(strings.Join(inputFiles, "\n")); err != nil {
-		return fmt.Errorf("writing to temp file: %w", err)
+		return "", fmt.Errorf("writing to temp file: %w", err)
 	}
 
-	return runFFmpeg(ctx, tmpFile.Name(), outputFilePath, opts)
+	return runFFmpeg(ctx, tmpFile.Name(), outputFilePath, outputArgs, opts)
 }
 
-func runFFmpeg(ctx context.Context, inputFileList, outputFilePath string, opts *combineOptions) error {
-	cmd := exec.CommandContext(
-		ctx,
-		"ffmpeg",
+func runFFmpeg(ctx context.Context, inputFileList, outputFilePath string, outputArgs []string, opts *combineOptions) (string, error) {
+	args := []string{
 		"-f", "concat",
 		"-safe", "0",
 		"-i", inputFileList,
-		"-c", "copy",
-		outputFilePath,
-	)
+	}
+	args = append(args, outputArgs...)
+	args = append(args, "-c", "copy", outputFilePath)
+
+	if err := execFFmpeg(ctx, args, opts.logger); err != nil {
+		return "", err
+	}
+	return formatCommand("ffmpeg", args), nil
+}
+
+// formatCommand renders a command line for display, quoting arguments that
+// contain spaces or quotes.
+func formatCommand(name string, args []string) string {
+	parts := []string{name}
+	for _, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " '\"") {
+			arg = strconv.Quote(arg)
+		}
+		parts = append(parts, arg)
+	}
+	return strings.Join(parts, " ")
+}
+
+// execFFmpeg runs FFmpeg with the given arguments.
+func execFFmpeg(ctx context.Context, args []string, logger *slog.Logger) error {
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
 
 	var stdErrBuff strings.Builder
 
 	// Suppress ffmpeg output unless debugging is enabled.
-	if opts.logger.Enabled(ctx, slog.LevelDebug) {
+	if logger.Enabled(ctx, slog.LevelDebug) {
 		cmd.Stderr = os.Stderr
 	} else {
 		cmd.Stderr = &stdErrBuff
@@ -258,8 +629,8 @@ func runFFmpeg(ctx context.Context, inputFileList, outputFilePath string, opts *
 
 	if err := cmd.Run(); err != nil {
 		// If debugging is off, print any captured stderr logs on failure.
-		if !opts.logger.Enabled(ctx, slog.LevelDebug) {
-			opts.logger.Error(stdErrBuff.String())
+		if !logger.Enabled(ctx, slog.LevelDebug) {
+			logger.Error(stdErrBuff.String())
 		}
 		return fmt.Errorf("running ffmpeg: %w", err)
 	}
@@ -267,6 +638,104 @@ func runFFmpeg(ctx context.Context, inputFileList, outputFilePath string, opts *
 	return nil
 }
 
+// trackMappingArgs returns the FFmpeg arguments that map every probed stream
+// into the output. GoPro data tracks use codecs unknown to FFmpeg, so they need
+// -copy_unknown and an explicit tag for the MP4 muxer to write them. Without
+// any streams, FFmpeg's default stream selection is used.
+func trackMappingArgs(streams []media.Stream) []string {
+	if len(streams) == 0 {
+		return nil
+	}
+
+	args := []string{"-map", "0", "-copy_unknown", "-map_metadata", "0", "-write_tmcd", "0"}
+	for _, s := range streams {
+		if s.CodecType == "data" && isFourCC(s.CodecTag) {
+			args = append(args, fmt.Sprintf("-tag:%d", s.Index), s.CodecTag)
+		}
+	}
+	return args
+}
+
+// creationTimeTag returns the FFmpeg metadata tag that sets the creation time
+// to t, in the format GoPro cameras write.
+func creationTimeTag(t time.Time) string {
+	return "creation_time=" + t.UTC().Format("2006-01-02T15:04:05.000000Z")
+}
+
+// metadataArgs returns the FFmpeg arguments that tag a combined video with its
+// recording time, the files it was built from, and the camera that recorded
+// them. Custom tags like the serial number are only written to MP4 files with
+// the use_metadata_tags flag.
+func metadataArgs(inv *media.Inventory, outputPath string, camera *gopro.HardwareInfo) []string {
+	var filenames []string
+	for _, file := range inv.Files {
+		filenames = append(filenames, file.Filename)
+	}
+
+	title := strings.TrimSuffix(filepath.Base(outputPath), filepath.Ext(outputPath))
+	creationTime := creationTimeTag(inv.Files[0].CreatedAt)
+
+	args := []string{
+		"-movflags", "+use_metadata_tags",
+		"-metadata", creationTime,
+		"-metadata:s", creationTime,
+		"-metadata", "title=" + title,
+		"-metadata", "comment=Combined from " + strings.Join(filenames, ", "),
+	}
+
+	if camera != nil {
+		args = append(args,
+			"-metadata", "make=GoPro",
+			"-metadata", "model="+camera.ModelName,
+			"-metadata", "serial_number="+camera.SerialNumber,
+		)
+	}
+
+	return args
+}
+
+// verifyTracks checks that the combined output kept every data track of the input.
+func verifyTracks(ctx context.Context, inputStreams []media.Stream, outputPath string) error {
+	outputStreams, err := media.ProbeStreams(ctx, outputPath)
+	if err != nil {
+		return err
+	}
+
+	kept := make(map[string]int)
+	for _, s := range outputStreams {
+		kept[s.CodecType+"/"+s.CodecTag]++
+	}
+
+	var missing []string
+	for _, s := range inputStreams {
+		key := s.CodecType + "/" + s.CodecTag
+		if kept[key] == 0 {
+			missing = append(missing, fmt.Sprintf("#%d %s (%s)", s.Index, s.CodecTag, s.CodecType))
+			continue
+		}
+		kept[key]--
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("streams missing from output: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// isFourCC reports whether tag is a printable four-character code, as opposed
+// to ffprobe's placeholder for an empty tag (e.g., "[0][0][0][0]").
+func isFourCC(tag string) bool {
+	if len(tag) != 4 {
+		return false
+	}
+	for _, r := range tag {
+		if r < ' ' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
+
 // String method for pretty printing.
 func (g GroupBy) String() string {
 	switch g {
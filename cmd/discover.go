@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/EarthmanMuons/herosync/internal/gopro"
+)
+
+// newDiscoverCmd constructs the "discover" subcommand.
+func newDiscoverCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "discover",
+		Short: "Find GoPros on the local network",
+		Long: `Find GoPros on the local network.
+
+Browses for cameras advertising the Open GoPro HTTP API via mDNS/DNS-SD on every
+network interface, and lists each one with the address to use as its host.`,
+		Args: cobra.NoArgs,
+		RunE: runDiscover,
+	}
+
+	cmd.Flags().Duration("timeout", gopro.DefaultDiscoveryTimeout, "how long to listen for cameras")
+
+	return cmd
+}
+
+// runDiscover is the entry point for the "discover" subcommand.
+func runDiscover(cmd *cobra.Command, args []string) error {
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+
+	services, err := gopro.Discover(timeout)
+	if err != nil {
+		return err
+	}
+
+	for _, svc := range services {
+		fmt.Printf("%s at %s\n", svc.Instance, svc.Addr())
+		fmt.Printf("  Host: %s\n", svc.Host)
+
+		ips := make([]string, len(svc.IPs))
+		for i, ip := range svc.IPs {
+			ips[i] = ip.String()
+		}
+		fmt.Printf("  Addresses: %s\n", strings.Join(ips, ", "))
+
+		for _, key := range slices.Sorted(maps.Keys(svc.TXT)) {
+			fmt.Printf("  %s: %s\n", key, svc.TXT[key])
+		}
+	}
+
+	return nil
+}
@@ -8,10 +8,14 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"slices"
 	"syscall"
+	"time"
 
+	"github.com/dustin/go-humanize"
 	"github.com/spf13/cobra"
 
+	"github.com/EarthmanMuons/herosync/config"
 	"github.com/EarthmanMuons/herosync/internal/fsutil"
 	"github.com/EarthmanMuons/herosync/internal/gopro"
 	"github.com/EarthmanMuons/herosync/internal/media"
@@ -19,11 +23,15 @@ import (
 
 type downloadOptions struct {
 	logger       *slog.Logger
+	cfg          *config.Config
 	client       *gopro.Client
 	inventory    *media.Inventory
 	incomingDir  string
+	ingest       *media.IngestLog
 	force        bool
 	keepOriginal bool
+	dryRun       bool
+	keepAlive    *keepAlive // set while transferring, if enabled
 }
 
 var activeDownloads = make(map[string]struct{})
@@ -43,6 +51,8 @@ If one or more [FILENAME] arguments are provided, only matching files will be af
 
 	cmd.Flags().BoolP("force", "f", false, "force re-download of existing files")
 	cmd.Flags().BoolP("keep-original", "k", false, "prevent deleting remote files after downloading")
+	cmd.Flags().Bool("sync-clock", false, "set the GoPro's clock first if it has drifted")
+	cmd.Flags().String("limit-rate", "", "cap the download speed (e.g., 20MB/s), overriding the configured schedule")
 
 	return cmd
 }
@@ -54,37 +64,128 @@ func runDownload(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	client, err := gopro.NewClient(logger, cfg.GoPro.Scheme, cfg.GoPro.Host)
+	// A --limit-rate flag replaces the configured schedule outright.
+	schedule, err := bandwidthSchedule(cfg)
 	if err != nil {
 		return err
 	}
+	if cmd.Flags().Changed("limit-rate") {
+		limit, _ := cmd.Flags().GetString("limit-rate")
+		rate, err := gopro.ParseRate(limit)
+		if err != nil {
+			return err
+		}
+		schedule = gopro.BandwidthSchedule{Default: rate}
+	}
+	// One limiter is shared by every camera's client, so the limit applies to
+	// all downloads together.
+	var clientOpts []gopro.Option
+	if schedule.Default != 0 || len(schedule.Windows) > 0 {
+		logger.Debug("limiting download rate", slog.String("current", schedule.RateAt(time.Now()).String()))
+		clientOpts = append(clientOpts, gopro.WithRateLimiter(gopro.NewRateLimiter(schedule)))
+	}
 
-	// Set up interrupt handling.
-	handleInterrupt(client)
-
-	inventory, err := loadFilteredInventory(ctx, cfg, client, args)
-	if err != nil {
+	// Apply retention first so the inventory reflects what remains on disk.
+	if err := enforceRetention(cmd, logger, cfg); err != nil {
 		return err
 	}
 
-	incomingDir := cfg.IncomingMediaDir()
+	autoSync := cfg.Clock.SyncOnDownload
+	if cmd.Flags().Changed("sync-clock") {
+		autoSync, _ = cmd.Flags().GetBool("sync-clock")
+	}
 	force, _ := cmd.Flags().GetBool("force")
 	keepOriginal, _ := cmd.Flags().GetBool("keep-original")
 
-	opts := downloadOptions{
-		logger:       logger,
-		client:       client,
-		inventory:    inventory,
-		incomingDir:  incomingDir,
-		force:        force,
-		keepOriginal: keepOriginal,
+	return forEachCamera(cmd, cfg, func(logger *slog.Logger, cam config.Camera, client *gopro.Client) error {
+		// Set up interrupt handling.
+		stop := handleInterrupt(client)
+		defer stop()
+
+		if autoSync {
+			if err := syncClockIfDrifted(ctx, logger, cfg, client, isDryRun(cmd)); err != nil {
+				logger.Warn("failed to sync camera clock", slog.Any("error", err))
+			}
+		}
+
+		inventory, err := loadFilteredInventory(ctx, cfg, client, cam.IncomingDir, args)
+		if err != nil {
+			return err
+		}
+
+		ingest, err := media.LoadIngestLog(cam.IncomingDir)
+		if err != nil {
+			return err
+		}
+
+		if err := ensureFreeSpace(logger, cfg, cam.IncomingDir, inventory.PendingSize(force, ingest), isDryRun(cmd)); err != nil {
+			return err
+		}
+
+		opts := downloadOptions{
+			logger:       logger,
+			cfg:          cfg,
+			client:       client,
+			inventory:    inventory,
+			incomingDir:  cam.IncomingDir,
+			ingest:       ingest,
+			force:        force,
+			keepOriginal: keepOriginal,
+			dryRun:       isDryRun(cmd),
+		}
+
+		return downloadInventory(ctx, &opts)
+	}, clientOpts...)
+}
+
+// bandwidthSchedule parses the configured download rate limits by time of day.
+func bandwidthSchedule(cfg *config.Config) (gopro.BandwidthSchedule, error) {
+	var schedule gopro.BandwidthSchedule
+
+	rate, err := gopro.ParseRate(cfg.Transfer.LimitRate)
+	if err != nil {
+		return schedule, err
 	}
+	schedule.Default = rate
 
-	return downloadInventory(ctx, &opts)
+	for _, w := range cfg.Transfer.Schedule {
+		start, err := gopro.ParseTimeOfDay(w.Start)
+		if err != nil {
+			return schedule, fmt.Errorf("invalid transfer schedule start: %w", err)
+		}
+		end, err := gopro.ParseTimeOfDay(w.End)
+		if err != nil {
+			return schedule, fmt.Errorf("invalid transfer schedule end: %w", err)
+		}
+		if start == end {
+			return schedule, fmt.Errorf("invalid transfer schedule %s-%s: start and end must differ", w.Start, w.End)
+		}
+		rate, err := gopro.ParseRate(w.LimitRate)
+		if err != nil {
+			return schedule, fmt.Errorf("invalid transfer schedule %s-%s: %w", w.Start, w.End, err)
+		}
+		schedule.Windows = append(schedule.Windows, gopro.RateWindow{Start: start, End: end, Rate: rate})
+	}
+
+	return schedule, nil
 }
 
 // downloadInventory handles downloading files based on their sync status.
 func downloadInventory(ctx context.Context, opts *downloadOptions) error {
+	if opts.dryRun {
+		planDownloads(opts)
+		return nil
+	}
+
+	if !slices.ContainsFunc(opts.inventory.Files, func(file media.File) bool { return file.NeedsDownload(opts.force, opts.ingest) }) {
+		opts.logger.Debug("no files to download")
+		return nil
+	}
+
+	if err := ensureCameraReady(ctx, opts.logger, opts.cfg, opts.client); err != nil {
+		return err
+	}
+
 	var errs []error
 
 	// Enable Turbo Transfer mode for faster download speeds.
@@ -101,32 +202,66 @@ func downloadInventory(ctx context.Context, opts *downloadOptions) error {
 		}
 	}()
 
+	// Keep the camera awake for the whole transfer window.
+	if interval := opts.cfg.Transfer.KeepAlive; interval > 0 {
+		opts.keepAlive = startKeepAlive(ctx, opts.logger, opts.client, interval)
+		defer func() {
+			opts.keepAlive.stop()
+			opts.keepAlive = nil
+		}()
+	}
+
+files:
 	for _, file := range opts.inventory.Files {
-		shouldDownload := shouldDownload(file, opts.force)
-		if !shouldDownload {
+		if !file.NeedsDownload(opts.force, opts.ingest) {
 			opts.logger.Debug("skipping file", slog.String("filename", file.Filename), slog.String("status", file.Status.String()))
 			continue
 		}
 
-		opts.logger.Info("downloading file", slog.String("filename", file.Filename), slog.String("status", file.Status.String()))
-
-		if err := downloadAndVerify(ctx, &file, opts); err != nil {
-			opts.logger.Error("failed to download", slog.String("filename", file.Filename), slog.Any("error", err))
-			errs = append(errs, err)
+		for attempt := 1; ; attempt++ {
+			// Pause while the camera isn't responding.
+			if opts.keepAlive != nil {
+				if err := opts.keepAlive.waitResponsive(ctx, opts.cfg.Transfer.ReadyTimeout); err != nil {
+					errs = append(errs, err)
+					break files
+				}
+			}
+
+			opts.logger.Info("downloading file", slog.String("filename", file.Filename), slog.String("status", file.Status.String()))
+
+			err := downloadAndVerify(ctx, &file, opts)
+			if errors.Is(err, errCameraUnresponsive) && attempt < maxOutageAttempts {
+				opts.logger.Warn("download interrupted by camera outage, retrying", slog.String("filename", file.Filename), slog.Int("attempt", attempt))
+				continue
+			}
+			if err != nil {
+				opts.logger.Error("failed to download", slog.String("filename", file.Filename), slog.Any("error", err))
+				errs = append(errs, err)
+			}
+			break
 		}
 	}
 	return errors.Join(errs...)
 }
 
-// shouldDownload determines whether a file should be downloaded.
-func shouldDownload(file media.File, force bool) bool {
-	switch file.Status {
-	case media.OnlyRemote:
-		return true
-	case media.OutOfSync, media.InSync:
-		return force
-	default:
-		return false
+// maxOutageAttempts is how many times a file is tried when camera outages
+// keep interrupting its transfer.
+const maxOutageAttempts = 3
+
+// planDownloads prints the files that would be downloaded without fetching them.
+func planDownloads(opts *downloadOptions) {
+	for _, file := range opts.inventory.Files {
+		if !file.NeedsDownload(opts.force, opts.ingest) {
+			continue
+		}
+
+		remotePath := fmt.Sprintf("%s/%s", file.Directory, file.Filename)
+		localPath := filepath.Join(fsutil.ShortenPath(opts.incomingDir), file.Filename)
+		printPlan("download %s (%s) to %s", remotePath, humanize.Bytes(uint64(file.Size)), localPath)
+
+		if !opts.keepOriginal {
+			printPlan("delete remote %s after download", remotePath)
+		}
 	}
 }
 
@@ -137,7 +272,15 @@ func downloadAndVerify(ctx context.Context, file *media.File, opts *downloadOpti
 	activeDownloads[downloadPath] = struct{}{}  // track active download
 	defer delete(activeDownloads, downloadPath) // cleanup tracking after completion
 
-	if err := opts.client.DownloadMediaFile(ctx, file.Directory, file.Filename, opts.incomingDir); err != nil {
+	// Give up on the transfer, to be retried, if the camera stops responding.
+	transferCtx, release := opts.keepAlive.guard(ctx)
+	err := opts.client.DownloadMediaFile(transferCtx, file.Directory, file.Filename, opts.incomingDir)
+	cause := context.Cause(transferCtx)
+	release()
+	if err != nil {
+		if errors.Is(cause, errCameraUnresponsive) && ctx.Err() == nil {
+			err = cause
+		}
 		return fmt.Errorf("failed to download file %s: %w", file.Filename, err)
 	}
 	opts.logger.Info("download complete", slog.String("filename", file.Filename))
@@ -152,6 +295,11 @@ func downloadAndVerify(ctx context.Context, file *media.File, opts *downloadOpti
 		return fmt.Errorf("failed to verify downloaded file: %w", err)
 	}
 
+	// Retention ages files from their arrival, as the mtime is the recording time.
+	if err := opts.ingest.MarkIngested(file.Filename); err != nil {
+		return err
+	}
+
 	// Delete the original remote file if --keep-original is not set.
 	if !opts.keepOriginal {
 		remotePath := fmt.Sprintf("%s/%s", file.Directory, file.Filename)
@@ -165,12 +313,17 @@ func downloadAndVerify(ctx context.Context, file *media.File, opts *downloadOpti
 	return nil
 }
 
-func handleInterrupt(client *gopro.Client) {
+func handleInterrupt(client *gopro.Client) (stop func()) {
 	sigChan := make(chan os.Signal, 1)
+	done := make(chan struct{})
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
 	go func() {
-		<-sigChan
+		select {
+		case <-sigChan:
+		case <-done:
+			return
+		}
 		fmt.Println("\nInterrupted! Cleaning up...")
 
 		// Remove any partial downloads.
@@ -187,4 +340,9 @@ func handleInterrupt(client *gopro.Client) {
 
 		os.Exit(1)
 	}()
+
+	return func() {
+		signal.Stop(sigChan)
+		close(done)
+	}
 }
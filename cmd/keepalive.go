@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/EarthmanMuons/herosync/internal/gopro"
+)
+
+// errCameraUnresponsive is the cause given to a transfer canceled because the
+// camera stopped answering keep-alives.
+var errCameraUnresponsive = errors.New("camera stopped responding to keep-alive")
+
+// abortAfterFailures is how many keep-alives in a row must fail before the
+// transfer in flight is canceled, so that a single ping lost while the camera
+// is busy sending doesn't restart a healthy transfer.
+const abortAfterFailures = 2
+
+// keepAlive pings the camera in the background so it doesn't go to sleep or
+// drop the connection during a long transfer, and tracks whether the camera
+// is still responding.
+type keepAlive struct {
+	client     *gopro.Client
+	logger     *slog.Logger
+	interval   time.Duration
+	responsive atomic.Bool
+	cancel     context.CancelFunc
+	done       sync.WaitGroup
+
+	mu    sync.Mutex
+	abort context.CancelCauseFunc // guarded by mu; cancels the transfer in flight
+}
+
+// startKeepAlive starts pinging the camera every interval until stop is called
+// or ctx is canceled.
+func startKeepAlive(ctx context.Context, logger *slog.Logger, client *gopro.Client, interval time.Duration) *keepAlive {
+	ctx, cancel := context.WithCancel(ctx)
+
+	k := &keepAlive{
+		client:   client,
+		logger:   logger,
+		interval: interval,
+		cancel:   cancel,
+	}
+	k.responsive.Store(true)
+
+	k.done.Add(1)
+	go func() {
+		defer k.done.Done()
+		k.run(ctx)
+	}()
+
+	return k
+}
+
+func (k *keepAlive) run(ctx context.Context) {
+	ticker := time.NewTicker(k.interval)
+	defer ticker.Stop()
+
+	var failedSince time.Time
+	var failures int
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		pingCtx, cancel := context.WithTimeout(ctx, max(k.interval, 5*time.Second))
+		err := k.client.KeepAlive(pingCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err != nil {
+			failures++
+		}
+		switch {
+		case err != nil && failedSince.IsZero():
+			failedSince = time.Now()
+			k.responsive.Store(false)
+			k.logger.Warn("camera stopped responding to keep-alive", slog.Any("error", err))
+		case err == nil && !failedSince.IsZero():
+			k.logger.Info("camera responding again", slog.Duration("outage", time.Since(failedSince).Round(time.Second)))
+			failedSince = time.Time{}
+			failures = 0
+			k.responsive.Store(true)
+		}
+		if failures == abortAfterFailures {
+			k.abortTransfer()
+		}
+	}
+}
+
+// guard returns a context for a single transfer that's canceled with
+// errCameraUnresponsive as its cause once the camera stops answering
+// keep-alives, so that the transfer can be retried when the camera is back
+// instead of waiting on a dead connection. Call release when the transfer is
+// over. A nil keepAlive returns ctx as is.
+func (k *keepAlive) guard(ctx context.Context) (_ context.Context, release func()) {
+	if k == nil {
+		return ctx, func() {}
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	k.mu.Lock()
+	k.abort = cancel
+	k.mu.Unlock()
+
+	return ctx, func() {
+		k.mu.Lock()
+		k.abort = nil
+		k.mu.Unlock()
+		cancel(nil)
+	}
+}
+
+// abortTransfer cancels the guarded transfer in flight, if any.
+func (k *keepAlive) abortTransfer() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.abort != nil {
+		k.logger.Warn("canceling transfer until the camera responds again")
+		k.abort(errCameraUnresponsive)
+		k.abort = nil
+	}
+}
+
+// stop ends the keep-alive pings and waits for the goroutine to exit.
+func (k *keepAlive) stop() {
+	k.cancel()
+	k.done.Wait()
+}
+
+// waitResponsive pauses until the camera answers keep-alives again, giving up
+// after timeout.
+func (k *keepAlive) waitResponsive(ctx context.Context, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for !k.responsive.Load() {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("camera not responding after %s", timeout)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(k.interval):
+		}
+	}
+	return nil
+}
@@ -2,9 +2,11 @@ package cmd
 
 import (
 	"fmt"
+	"log/slog"
 
 	"github.com/spf13/cobra"
 
+	"github.com/EarthmanMuons/herosync/config"
 	"github.com/EarthmanMuons/herosync/internal/gopro"
 )
 
@@ -21,24 +23,24 @@ func newListCmd() *cobra.Command {
 
 // runList is the entry point for the "list" subcommand.
 func runList(cmd *cobra.Command, args []string) error {
-	ctx, logger, cfg, err := contextLoggerConfig(cmd)
+	ctx, _, cfg, err := contextLoggerConfig(cmd)
 	if err != nil {
 		return err
 	}
 
-	client, err := gopro.NewClient(logger, cfg.GoPro.Scheme, cfg.GoPro.Host)
-	if err != nil {
-		return err
-	}
-
-	inventory, err := loadFilteredInventory(ctx, cfg, client, args)
-	if err != nil {
-		return err
-	}
-
-	for _, file := range inventory.Files {
-		fmt.Println(file)
-	}
-
-	return nil
+	return forEachCamera(cmd, cfg, func(logger *slog.Logger, cam config.Camera, client *gopro.Client) error {
+		inventory, err := loadFilteredInventory(ctx, cfg, client, cam.IncomingDir, args)
+		if err != nil {
+			return err
+		}
+
+		if cam.Name != "" {
+			fmt.Printf("== %s ==\n", cam.Name)
+		}
+		for _, file := range inventory.Files {
+			fmt.Println(file)
+		}
+
+		return nil
+	})
 }
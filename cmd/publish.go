@@ -1,11 +1,16 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,8 +20,14 @@ import (
 	"google.golang.org/api/youtube/v3"
 
 	"github.com/EarthmanMuons/herosync/config"
+	"github.com/EarthmanMuons/herosync/internal/fsutil"
+	"github.com/EarthmanMuons/herosync/internal/geo"
+	"github.com/EarthmanMuons/herosync/internal/gpmf"
 	"github.com/EarthmanMuons/herosync/internal/media"
+	"github.com/EarthmanMuons/herosync/internal/pubrecord"
+	"github.com/EarthmanMuons/herosync/internal/sidecar"
 	"github.com/EarthmanMuons/herosync/internal/ytclient"
+	"github.com/EarthmanMuons/herosync/internal/ytquota"
 )
 
 type publishOptions struct {
@@ -24,7 +35,14 @@ type publishOptions struct {
 	cfg               *config.Config
 	inventory         *media.Inventory
 	service           *youtube.Service
+	ledger            *ytquota.Ledger
+	record            *pubrecord.Record
+	gazetteer         *geo.Gazetteer
+	fallback          *geo.Point // location for videos without GPS telemetry, if any
 	uploadedDurations map[string]map[uint64]struct{}
+	wait              bool
+	waitTimeout       time.Duration
+	dryRun            bool
 }
 
 var (
@@ -33,7 +51,11 @@ var (
 	dateRe    = regexp.MustCompile(`^daily-(\d{4}-\d{2}-\d{2})$`)
 )
 
-const durationTolerance = 100 // max milliseconds difference to consider videos identical
+const (
+	durationTolerance      = 100 // max milliseconds difference to consider videos identical
+	processingPollInterval = 30 * time.Second
+	youtubeBackend         = "youtube"
+)
 
 // newPublishCmd constructs the "publish" subcommand.
 func newPublishCmd() *cobra.Command {
@@ -44,6 +66,10 @@ func newPublishCmd() *cobra.Command {
 		Args:    cobra.ArbitraryArgs,
 		RunE:    runPublish,
 	}
+
+	cmd.Flags().Bool("wait", false, "wait for YouTube to finish processing each upload")
+	cmd.Flags().Duration("wait-timeout", time.Hour, "maximum time to wait for processing per video")
+
 	return cmd
 }
 
@@ -54,6 +80,15 @@ func runPublish(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	var fallback *geo.Point
+	if cfg.Location.Fallback != "" {
+		p, err := geo.ParsePoint(cfg.Location.Fallback)
+		if err != nil {
+			return fmt.Errorf("invalid fallback location: %w", err)
+		}
+		fallback = &p
+	}
+
 	// Only look at local processed files that are ready to upload.
 	inventory, err := media.NewProcessedInventory(ctx, cfg.OutgoingMediaDir())
 	if err != nil {
@@ -68,6 +103,81 @@ func runPublish(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	ledger, err := ytquota.Load(defaultQuotaLedgerPath(), cfg.YouTube.DailyQuota)
+	if err != nil {
+		return err
+	}
+
+	record, err := pubrecord.Load(defaultPublicationRecordPath())
+	if err != nil {
+		return err
+	}
+
+	// Don't spend anything unless the budget covers at least one full upload.
+	minUnits := ytquota.CostChannelsList + ytquota.CostSearchList + ytquota.CostVideosList + ytquota.CostVideosInsert
+	if !ledger.CanSpend(minUnits) {
+		logQuotaDeferral(logger, ledger, countPendingUploads(inventory.Files, record, nil))
+		return applyPostPublishActions(logger, cfg, record, isDryRun(cmd))
+	}
+
+	// A dry run plans from the publication record rather than asking YouTube,
+	// so that it neither needs the network nor spends any quota.
+	var service *youtube.Service
+	uploadedDurations := make(map[string]map[uint64]struct{})
+	if !isDryRun(cmd) {
+		service, uploadedDurations, err = connectYouTube(ctx, logger, ledger)
+		if err != nil {
+			if errors.Is(err, ytquota.ErrBudgetExceeded) || ytquota.IsQuotaExceeded(err) {
+				return handleQuotaExceeded(logger, ledger, countPendingUploads(inventory.Files, record, nil))
+			}
+			return err
+		}
+	}
+
+	var gazetteer *geo.Gazetteer
+	if cfg.Location.Gazetteer != "" {
+		gazetteer, err = geo.LoadGazetteer(cfg.Location.Gazetteer)
+		if err != nil {
+			return err
+		}
+	}
+
+	wait, _ := cmd.Flags().GetBool("wait")
+	waitTimeout, _ := cmd.Flags().GetDuration("wait-timeout")
+
+	opts := &publishOptions{
+		logger:            logger,
+		cfg:               cfg,
+		inventory:         inventory,
+		service:           service,
+		ledger:            ledger,
+		record:            record,
+		gazetteer:         gazetteer,
+		fallback:          fallback,
+		uploadedDurations: uploadedDurations,
+		wait:              wait,
+		waitTimeout:       waitTimeout,
+		dryRun:            isDryRun(cmd),
+	}
+
+	// Resolve uploads from earlier runs that were never confirmed.
+	if opts.wait && !opts.dryRun {
+		if err := confirmPendingUploads(ctx, opts); err != nil {
+			return err
+		}
+	}
+
+	if err := uploadVideos(ctx, opts); err != nil {
+		return err
+	}
+
+	return applyPostPublishActions(logger, cfg, record, opts.dryRun)
+}
+
+// connectYouTube creates the YouTube service and returns it along with the
+// recording dates and durations of the videos already uploaded, used to avoid
+// uploading them again.
+func connectYouTube(ctx context.Context, logger *slog.Logger, ledger *ytquota.Ledger) (*youtube.Service, map[string]map[uint64]struct{}, error) {
 	scopes := []string{
 		youtube.YoutubeReadonlyScope,
 		youtube.YoutubeUploadScope,
@@ -80,20 +190,23 @@ func runPublish(cmd *cobra.Command, args []string) error {
 
 	service, err := youtube.NewService(ctx, option.WithHTTPClient(client))
 	if err != nil {
-		return fmt.Errorf("unable to create YouTube service: %v", err)
+		return nil, nil, fmt.Errorf("unable to create YouTube service: %v", err)
 	}
 
+	if err := ledger.Spend("channels.list", ytquota.CostChannelsList); err != nil {
+		return nil, nil, err
+	}
 	call := service.Channels.List([]string{"snippet"}).Mine(true)
 	resp, err := call.Do()
 	if err != nil {
-		return fmt.Errorf("making API call: %v", err)
+		return nil, nil, fmt.Errorf("making API call: %w", err)
 	}
 
 	logger.Debug("connected to youtube", slog.String("channel", resp.Items[0].Snippet.Title))
 
-	uploadedVideos, err := getUploadedVideos(service)
+	uploadedVideos, err := getUploadedVideos(service, ledger)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	// Map of recording date to a set of durations (to handle multiple uploads on the same day).
@@ -114,22 +227,25 @@ func runPublish(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	opts := &publishOptions{
-		logger:            logger,
-		cfg:               cfg,
-		inventory:         inventory,
-		service:           service,
-		uploadedDurations: uploadedDurations,
-	}
-
-	return uploadVideos(opts)
+	return service, uploadedDurations, nil
 }
 
 func defaultClientSecretPath() string {
 	return filepath.Join(xdg.ConfigHome, "herosync", "client_secret.json")
 }
 
-func getUploadedVideos(service *youtube.Service) ([]*youtube.Video, error) {
+func defaultPublicationRecordPath() string {
+	return filepath.Join(xdg.StateHome, "herosync", "published.json")
+}
+
+func defaultQuotaLedgerPath() string {
+	return filepath.Join(xdg.StateHome, "herosync", "youtube_quota.json")
+}
+
+func getUploadedVideos(service *youtube.Service, ledger *ytquota.Ledger) ([]*youtube.Video, error) {
+	if err := ledger.Spend("search.list", ytquota.CostSearchList); err != nil {
+		return nil, err
+	}
 	call := service.Search.List([]string{"snippet"}).
 		ForMine(true).
 		Type("video").
@@ -138,7 +254,7 @@ func getUploadedVideos(service *youtube.Service) ([]*youtube.Video, error) {
 
 	resp, err := call.Do()
 	if err != nil {
-		return nil, fmt.Errorf("making API call: %v", err)
+		return nil, fmt.Errorf("making API call: %w", err)
 	}
 
 	var videoIDs []string
@@ -146,39 +262,76 @@ func getUploadedVideos(service *youtube.Service) ([]*youtube.Video, error) {
 		videoIDs = append(videoIDs, item.Id.VideoId)
 	}
 
-	return getVideoDetails(service, videoIDs)
+	return getVideoDetails(service, ledger, videoIDs)
 }
 
-func getVideoDetails(service *youtube.Service, videoIDs []string) ([]*youtube.Video, error) {
+func getVideoDetails(service *youtube.Service, ledger *ytquota.Ledger, videoIDs []string) ([]*youtube.Video, error) {
 	if len(videoIDs) == 0 {
 		return nil, nil
 	}
 
+	if err := ledger.Spend("videos.list", ytquota.CostVideosList); err != nil {
+		return nil, err
+	}
+
 	call := service.Videos.List([]string{"fileDetails", "recordingDetails", "snippet"}).Id(videoIDs...)
 	videoResponse, err := call.Do()
 	if err != nil {
-		return nil, fmt.Errorf("fetching video details: %v", err)
+		return nil, fmt.Errorf("fetching video details: %w", err)
 	}
 
 	return videoResponse.Items, nil
 }
 
-func uploadVideos(opts *publishOptions) error {
-	for _, file := range opts.inventory.Files {
+func uploadVideos(ctx context.Context, opts *publishOptions) error {
+	plannedUnits := 0 // quota that a dry run would have spent so far
+	if opts.dryRun {
+		plannedUnits = ytquota.CostChannelsList + ytquota.CostSearchList + ytquota.CostVideosList
+	}
+
+	for i, file := range opts.inventory.Files {
 		key := formatRecordingDate(file.CreatedAt)
 
+		// Without asking YouTube, a dry run relies on the publication record.
+		if opts.dryRun {
+			if entry, ok := opts.record.Get(file.Filename); ok && entry.Size == file.Size {
+				opts.logger.Info("skipping already uploaded video", slog.String("filename", file.Filename))
+				continue
+			}
+		}
+
 		if !shouldUpload(key, file.Duration, opts.uploadedDurations) {
 			opts.logger.Info("skipping already uploaded video", slog.String("filename", file.Filename))
 			continue
 		}
 
+		// Leave the rest of the queue for the next run once the budget runs out.
+		if !opts.ledger.CanSpend(plannedUnits + ytquota.CostVideosInsert) {
+			logQuotaDeferral(opts.logger, opts.ledger, countPendingUploads(opts.inventory.Files[i:], opts.record, opts.uploadedDurations))
+			return nil
+		}
+
 		// Update the durations map for this date.
 		if _, exists := opts.uploadedDurations[key]; !exists {
 			opts.uploadedDurations[key] = make(map[uint64]struct{})
 		}
 		opts.uploadedDurations[key][file.Duration] = struct{}{}
 
-		title := generateTitle(opts.cfg, file.Filename)
+		location, place := recordingLocation(opts, file)
+		data := templateData(opts.logger, file, place)
+		title := expandTemplate(opts.cfg.Video.Title, data)
+		description := expandTemplate(opts.cfg.Video.Description, data)
+
+		if opts.dryRun {
+			if location != nil {
+				printPlan("upload %s as %q at %s", file.Filename, title, geo.Point{Latitude: location.Latitude, Longitude: location.Longitude})
+			} else {
+				printPlan("upload %s as %q", file.Filename, title)
+			}
+			plannedUnits += ytquota.CostVideosInsert
+			continue
+		}
+
 		opts.logger.Info("uploading video", slog.String("filename", file.Filename), slog.String("title", title))
 
 		// Open video file.
@@ -190,26 +343,163 @@ func uploadVideos(opts *publishOptions) error {
 		}
 		defer videoFile.Close()
 
-		videoID, err := processUpload(file, title, videoFile, opts)
+		videoID, err := processUpload(file, title, description, location, videoFile, opts)
 		if err != nil {
+			if ytquota.IsQuotaExceeded(err) {
+				return handleQuotaExceeded(opts.logger, opts.ledger, 1+countPendingUploads(opts.inventory.Files[i+1:], opts.record, opts.uploadedDurations))
+			}
 			opts.logger.Error("uploading video", slog.String("filename", file.Filename), slog.Any("error", err))
 			continue
 		}
 
 		opts.logger.Info("video uploaded successfully", slog.String("title", title), slog.String("video-id", videoID))
+
+		entry := &pubrecord.Entry{
+			Filename:   file.Filename,
+			Backend:    youtubeBackend,
+			VideoID:    videoID,
+			Title:      title,
+			Size:       file.Size,
+			UploadedAt: time.Now(),
+			State:      pubrecord.Uploaded,
+		}
+		if err := opts.record.Put(entry); err != nil {
+			return err
+		}
+
+		if opts.wait {
+			if err := waitForProcessing(ctx, entry, opts); err != nil {
+				if errors.Is(err, ytquota.ErrBudgetExceeded) || ytquota.IsQuotaExceeded(err) {
+					return handleQuotaExceeded(opts.logger, opts.ledger, countPendingUploads(opts.inventory.Files[i+1:], opts.record, opts.uploadedDurations))
+				}
+				opts.logger.Warn("video processing not confirmed", slog.String("filename", file.Filename), slog.Any("error", err))
+			}
+		}
+	}
+	return nil
+}
+
+// confirmPendingUploads waits for the outcome of uploads recorded by earlier runs.
+func confirmPendingUploads(ctx context.Context, opts *publishOptions) error {
+	for _, entry := range opts.record.Pending() {
+		if entry.Backend != youtubeBackend {
+			continue
+		}
+
+		if err := waitForProcessing(ctx, entry, opts); err != nil {
+			if errors.Is(err, ytquota.ErrBudgetExceeded) || ytquota.IsQuotaExceeded(err) {
+				return handleQuotaExceeded(opts.logger, opts.ledger, countPendingUploads(opts.inventory.Files, opts.record, opts.uploadedDurations))
+			}
+			opts.logger.Warn("video processing not confirmed", slog.String("filename", entry.Filename), slog.Any("error", err))
+		}
 	}
 	return nil
 }
 
+// waitForProcessing polls YouTube until the uploaded video reaches a final
+// state, then records the outcome.
+func waitForProcessing(ctx context.Context, entry *pubrecord.Entry, opts *publishOptions) error {
+	ctx, cancel := context.WithTimeout(ctx, opts.waitTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(processingPollInterval)
+	defer ticker.Stop()
+
+	opts.logger.Info("waiting for video processing", slog.String("filename", entry.Filename), slog.String("video-id", entry.VideoID))
+
+	for {
+		state, reason, err := fetchVideoState(ctx, opts.service, opts.ledger, entry.VideoID)
+		if err != nil {
+			return err
+		}
+
+		if state.IsFinal() {
+			entry.State = state
+			entry.Reason = reason
+			entry.CheckedAt = time.Now()
+			if err := opts.record.Put(entry); err != nil {
+				return err
+			}
+
+			if state == pubrecord.Processed {
+				opts.logger.Info("video processed", slog.String("filename", entry.Filename), slog.String("video-id", entry.VideoID))
+			} else {
+				opts.logger.Error("video not published",
+					slog.String("filename", entry.Filename),
+					slog.String("video-id", entry.VideoID),
+					slog.String("state", string(state)),
+					slog.String("reason", reason),
+				)
+			}
+			return nil
+		}
+
+		opts.logger.Debug("video still processing", slog.String("video-id", entry.VideoID))
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for video %s: %w", entry.VideoID, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// fetchVideoState retrieves the current processing state of an uploaded video.
+func fetchVideoState(ctx context.Context, service *youtube.Service, ledger *ytquota.Ledger, videoID string) (pubrecord.State, string, error) {
+	if err := ledger.Spend("videos.list", ytquota.CostVideosList); err != nil {
+		return "", "", err
+	}
+
+	resp, err := service.Videos.List([]string{"processingDetails", "status"}).Id(videoID).Context(ctx).Do()
+	if err != nil {
+		return "", "", fmt.Errorf("fetching video status: %w", err)
+	}
+
+	if len(resp.Items) == 0 {
+		return pubrecord.Deleted, "video not found", nil
+	}
+
+	state, reason := videoState(resp.Items[0])
+	return state, reason, nil
+}
+
+// videoState maps the YouTube upload and processing status to a publication state.
+func videoState(video *youtube.Video) (pubrecord.State, string) {
+	if video.Status != nil {
+		switch video.Status.UploadStatus {
+		case "processed":
+			return pubrecord.Processed, ""
+		case "failed":
+			return pubrecord.Failed, video.Status.FailureReason
+		case "rejected":
+			return pubrecord.Rejected, video.Status.RejectionReason
+		case "deleted":
+			return pubrecord.Deleted, ""
+		}
+	}
+
+	if pd := video.ProcessingDetails; pd != nil {
+		switch pd.ProcessingStatus {
+		case "succeeded":
+			return pubrecord.Processed, ""
+		case "failed", "terminated":
+			return pubrecord.Failed, pd.ProcessingFailureReason
+		}
+	}
+
+	return pubrecord.Uploaded, ""
+}
+
 // processUpload handles the actual API call for a single video upload.
-func processUpload(file media.File, title string, videoFile *os.File, opts *publishOptions) (string, error) {
+func processUpload(file media.File, title, description string, location *youtube.GeoPoint, videoFile *os.File, opts *publishOptions) (string, error) {
 	upload := &youtube.Video{
 		RecordingDetails: &youtube.VideoRecordingDetails{
 			RecordingDate: file.CreatedAt.Format(time.RFC3339),
+			Location:      location,
 		},
 		Snippet: &youtube.VideoSnippet{
 			Title:       title,
-			Description: opts.cfg.Video.Description,
+			Description: description,
 			CategoryId:  opts.cfg.Video.CategoryID,
 		},
 		Status: &youtube.VideoStatus{
@@ -222,6 +512,10 @@ func processUpload(file media.File, title string, videoFile *os.File, opts *publ
 		upload.Snippet.Tags = strings.Split(trimmedTags, ",")
 	}
 
+	if err := opts.ledger.Spend("videos.insert", ytquota.CostVideosInsert); err != nil {
+		return "", err
+	}
+
 	call := opts.service.Videos.Insert([]string{"recordingDetails", "snippet", "status"}, upload)
 	resp, err := call.Media(videoFile).
 		ProgressUpdater(func(current, _ int64) {
@@ -240,6 +534,148 @@ func processUpload(file media.File, title string, videoFile *os.File, opts *publ
 	return resp.Id, nil
 }
 
+// applyPostPublishActions keeps, archives, or deletes the outgoing files whose
+// publication has been confirmed, according to the configured action.
+func applyPostPublishActions(logger *slog.Logger, cfg *config.Config, record *pubrecord.Record, dryRun bool) error {
+	action := cfg.YouTube.PostPublish
+	if action == "keep" {
+		return nil
+	}
+
+	outgoingDir := cfg.OutgoingMediaDir()
+	entries, err := confirmedOutgoingFiles(outgoingDir, record, youtubeBackend)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		path := filepath.Join(outgoingDir, entry.Filename)
+
+		switch action {
+		case "archive":
+			dst, err := fsutil.GenerateUniqueFilename(filepath.Join(cfg.ArchiveMediaDir(), entry.Filename))
+			if err != nil {
+				return err
+			}
+			if dryRun {
+				printPlan("move published %s to %s", fsutil.ShortenPath(path), fsutil.ShortenPath(dst))
+				continue
+			}
+			if err := fsutil.MoveFile(path, dst); err != nil {
+				logger.Error("failed to archive published file", slog.String("path", path), slog.Any("error", err))
+				continue
+			}
+			if err := moveCompanionFiles(path, dst); err != nil {
+				logger.Warn("failed to archive sidecars", slog.String("path", path), slog.Any("error", err))
+			}
+			logger.Info("published file archived", slog.String("filename", entry.Filename), slog.String("path", dst))
+
+		case "delete":
+			deleteAt := entry.CheckedAt.AddDate(0, 0, cfg.YouTube.DeleteAfter)
+			if time.Now().Before(deleteAt) {
+				logger.Debug("keeping published file until retention expires", slog.String("filename", entry.Filename), slog.Time("delete-at", deleteAt))
+				continue
+			}
+			if dryRun {
+				printPlan("delete published %s (video %s)", fsutil.ShortenPath(path), entry.VideoID)
+				continue
+			}
+			if err := os.Remove(path); err != nil {
+				logger.Error("failed to delete published file", slog.String("path", path), slog.Any("error", err))
+				continue
+			}
+			if err := removeCompanionFiles(path); err != nil {
+				logger.Warn("failed to delete sidecars", slog.String("path", path), slog.Any("error", err))
+			}
+			logger.Info("published file deleted", slog.String("filename", entry.Filename))
+		}
+	}
+
+	return nil
+}
+
+// confirmedOutgoingFiles returns the publication entries of files in the
+// outgoing directory whose publication has been confirmed. An empty backend
+// matches entries from any backend.
+func confirmedOutgoingFiles(outgoingDir string, record *pubrecord.Record, backend string) ([]*pubrecord.Entry, error) {
+	dirEntries, err := os.ReadDir(outgoingDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading directory: %w", err)
+	}
+
+	var confirmed []*pubrecord.Entry
+	for _, dirEntry := range dirEntries {
+		if !dirEntry.Type().IsRegular() {
+			continue
+		}
+
+		entry, ok := record.Get(dirEntry.Name())
+		if !ok || !entry.Confirmed() || (backend != "" && entry.Backend != backend) {
+			continue
+		}
+
+		info, err := dirEntry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("stat file: %w", err)
+		}
+		if !entry.Matches(info) {
+			continue
+		}
+
+		confirmed = append(confirmed, entry)
+	}
+
+	return confirmed, nil
+}
+
+// handleQuotaExceeded records that the API rejected a call for lack of quota and
+// stops publishing cleanly; the remaining videos are picked up on the next run.
+func handleQuotaExceeded(logger *slog.Logger, ledger *ytquota.Ledger, pending int) error {
+	logger.Warn("youtube reported the daily quota as exceeded")
+	if err := ledger.MarkExhausted(); err != nil {
+		return err
+	}
+	logQuotaDeferral(logger, ledger, pending)
+	return nil
+}
+
+// logQuotaDeferral reports that uploads are postponed until the quota resets.
+func logQuotaDeferral(logger *slog.Logger, ledger *ytquota.Ledger, pending int) {
+	logger.Warn("deferring uploads until quota resets",
+		slog.Int("pending", pending),
+		slog.Int("remaining-units", ledger.Remaining()),
+		slog.Time("resets-at", ledger.ResetsAt()),
+	)
+}
+
+// countPendingUploads returns how many of the files would still be uploaded,
+// leaving out those already published or duplicating an earlier file in the
+// list. A nil uploadedDurations means the videos on YouTube aren't known yet.
+func countPendingUploads(files []media.File, record *pubrecord.Record, uploadedDurations map[string]map[uint64]struct{}) int {
+	planned := make(map[string]map[uint64]struct{})
+
+	count := 0
+	for _, file := range files {
+		if entry, ok := record.Get(file.Filename); ok && entry.Size == file.Size {
+			continue
+		}
+
+		key := formatRecordingDate(file.CreatedAt)
+		if !shouldUpload(key, file.Duration, uploadedDurations) || !shouldUpload(key, file.Duration, planned) {
+			continue
+		}
+		if _, exists := planned[key]; !exists {
+			planned[key] = make(map[uint64]struct{})
+		}
+		planned[key][file.Duration] = struct{}{}
+		count++
+	}
+	return count
+}
+
 // formatRecordingDate returns a formatted date string truncated to midnight (UTC).
 func formatRecordingDate(t time.Time) string {
 	truncated := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
@@ -265,16 +701,95 @@ func withinTolerance(a, b uint64) bool {
 	return b-a <= durationTolerance
 }
 
-func generateTitle(cfg *config.Config, filename string) string {
-	metadata := extractMetadata(filename)
-	title := cfg.Video.Title
+// recordingLocation returns where the video was recorded, taken from the first
+// GPS fix in its telemetry or else the configured fallback location, along with
+// the nearest place name from the gazetteer, if any.
+func recordingLocation(opts *publishOptions, file media.File) (*youtube.GeoPoint, string) {
+	point, ok := telemetryLocation(opts.logger, file)
+	if !ok && opts.fallback != nil {
+		point, ok = *opts.fallback, true
+	}
+	if !ok {
+		return nil, ""
+	}
+
+	var place string
+	if opts.gazetteer != nil {
+		if p, found := opts.gazetteer.Nearest(point, opts.cfg.Location.MaxDistance); found {
+			place = p.Name
+		}
+	}
+
+	location := &youtube.GeoPoint{
+		Latitude:  point.Latitude,
+		Longitude: point.Longitude,
+		Altitude:  point.Altitude,
+	}
+	return location, place
+}
+
+// telemetryLocation returns the first GPS fix recorded in the video file.
+func telemetryLocation(logger *slog.Logger, file media.File) (geo.Point, bool) {
+	t, err := gpmf.Extract(filepath.Join(file.Directory, file.Filename), file.CreatedAt)
+	if err != nil {
+		if !errors.Is(err, gpmf.ErrNoTelemetry) {
+			logger.Warn("failed to read telemetry", slog.String("filename", file.Filename), slog.Any("error", err))
+		}
+		return geo.Point{}, false
+	}
+
+	fix, ok := t.FirstFix()
+	if !ok {
+		return geo.Point{}, false
+	}
+	return geo.Point{Latitude: fix.Latitude, Longitude: fix.Longitude, Altitude: fix.Altitude}, true
+}
+
+// templateData returns the values available to the title and description
+// templates. Values from the video's sidecar, if it has one, are added to those
+// parsed from the filename.
+func templateData(logger *slog.Logger, file media.File, place string) map[string]string {
+	data := extractMetadata(file.Filename)
+	data["place"] = place
+
+	s, err := sidecar.Read(filepath.Join(file.Directory, file.Filename))
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			logger.Warn("failed to read sidecar", slog.String("filename", file.Filename), slog.Any("error", err))
+		}
+		return data
+	}
+
+	var mediaIDs []string
+	for _, src := range s.Sources {
+		if id := strconv.Itoa(src.MediaID); src.MediaID != 0 && !slices.Contains(mediaIDs, id) {
+			mediaIDs = append(mediaIDs, id)
+		}
+	}
+
+	data["sources"] = strings.Join(s.SourceFilenames(), ", ")
+	data["source_count"] = strconv.Itoa(len(s.Sources))
+	data["media_ids"] = strings.Join(mediaIDs, ", ")
+	data["group_by"] = s.GroupBy
+	data["recorded_at"] = s.CreatedAt.Local().Format(time.DateTime)
+	if s.Camera != nil {
+		data["camera_model"] = s.Camera.Model
+		data["camera_serial"] = s.Camera.Serial
+		data["camera_firmware"] = s.Camera.Firmware
+	}
+
+	return data
+}
 
-	for key, value := range metadata {
+// expandTemplate replaces "${key}" placeholders in the template with values
+// from data.
+func expandTemplate(template string, data map[string]string) string {
+	for key, value := range data {
 		placeholder := fmt.Sprintf("${%s}", key)
-		title = strings.ReplaceAll(title, placeholder, value)
+		template = strings.ReplaceAll(template, placeholder, value)
 	}
 
-	return strings.TrimSpace(title)
+	return strings.TrimSpace(template)
 }
 
 // extractMetadata parses the filename to extract metadata, including media ID or date
@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/api/option"
+	"google.golang.org/api/youtube/v3"
+
+	"github.com/EarthmanMuons/herosync/internal/media"
+	"github.com/EarthmanMuons/herosync/internal/sidecar"
+	"github.com/EarthmanMuons/herosync/internal/ytquota"
+)
+
+func TestExtractMetadata(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     map[string]string
+	}{
+		{
+			filename: "gopro-0042.mp4",
+			want:     map[string]string{"counter": "", "type": "chapters", "media_id": "42", "identifier": "42"},
+		},
+		{
+			filename: "gopro-0042_2.mp4",
+			want:     map[string]string{"counter": "2", "type": "chapters", "media_id": "42", "identifier": "42"},
+		},
+		{
+			filename: "daily-2025-06-01.mp4",
+			want:     map[string]string{"counter": "", "type": "date", "date": "2025-06-01", "identifier": "2025-06-01"},
+		},
+		{
+			filename: "daily-2025-06-01_10.mp4",
+			want:     map[string]string{"counter": "10", "type": "date", "date": "2025-06-01", "identifier": "2025-06-01"},
+		},
+		{
+			filename: "holiday.mp4",
+			want:     map[string]string{"counter": "", "type": "unknown", "identifier": "holiday"},
+		},
+		{
+			filename: "daily-2025-6-1.mp4",
+			want:     map[string]string{"counter": "", "type": "unknown", "identifier": "daily-2025-6-1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			if got := extractMetadata(tt.filename); !maps.Equal(got, tt.want) {
+				t.Errorf("extractMetadata(%q) = %v, want %v", tt.filename, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandTemplate(t *testing.T) {
+	data := map[string]string{"date": "2025-06-01", "place": "Seattle", "counter": ""}
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{name: "placeholders", template: "Ride on ${date} in ${place}", want: "Ride on 2025-06-01 in Seattle"},
+		{name: "repeated placeholder", template: "${place}, ${place}", want: "Seattle, Seattle"},
+		{name: "empty value trimmed", template: "Ride ${counter}", want: "Ride"},
+		{name: "unknown placeholder kept", template: "${title}", want: "${title}"},
+		{name: "unbraced name kept", template: "$date", want: "$date"},
+		{name: "no placeholders", template: "  Plain title  ", want: "Plain title"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expandTemplate(tt.template, data); got != tt.want {
+				t.Errorf("expandTemplate(%q) = %q, want %q", tt.template, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateData(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	recorded := time.Date(2025, 6, 1, 9, 30, 0, 0, time.Local)
+
+	tests := []struct {
+		name    string
+		sidecar *sidecar.Sidecar // nil means no sidecar file
+		want    map[string]string
+	}{
+		{
+			name: "without sidecar",
+			want: map[string]string{"counter": "", "type": "chapters", "media_id": "42", "identifier": "42", "place": "Seattle"},
+		},
+		{
+			name: "with sidecar",
+			sidecar: &sidecar.Sidecar{
+				CreatedAt: recorded,
+				GroupBy:   "media-id",
+				Sources: []sidecar.Source{
+					{Filename: "GX010042.MP4", MediaID: 42, Chapter: 1},
+					{Filename: "GX020042.MP4", MediaID: 42, Chapter: 2},
+					{Filename: "GX010043.MP4", MediaID: 43, Chapter: 1},
+				},
+				Camera: &sidecar.Camera{Model: "HERO12 Black", Serial: "C3501324500000", Firmware: "H23.01.02.32.00"},
+			},
+			want: map[string]string{
+				"counter":         "",
+				"type":            "chapters",
+				"media_id":        "42",
+				"identifier":      "42",
+				"place":           "Seattle",
+				"sources":         "GX010042.MP4, GX020042.MP4, GX010043.MP4",
+				"source_count":    "3",
+				"media_ids":       "42, 43",
+				"group_by":        "media-id",
+				"recorded_at":     "2025-06-01 09:30:00",
+				"camera_model":    "HERO12 Black",
+				"camera_serial":   "C3501324500000",
+				"camera_firmware": "H23.01.02.32.00",
+			},
+		},
+		{
+			name: "sidecar without camera or media IDs",
+			sidecar: &sidecar.Sidecar{
+				CreatedAt: recorded,
+				GroupBy:   "date",
+				Sources:   []sidecar.Source{{Filename: "GX010042.MP4"}},
+			},
+			want: map[string]string{
+				"counter":      "",
+				"type":         "chapters",
+				"media_id":     "42",
+				"identifier":   "42",
+				"place":        "Seattle",
+				"sources":      "GX010042.MP4",
+				"source_count": "1",
+				"media_ids":    "",
+				"group_by":     "date",
+				"recorded_at":  "2025-06-01 09:30:00",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := media.File{Directory: t.TempDir(), Filename: "gopro-0042.mp4"}
+			if tt.sidecar != nil {
+				if err := sidecar.Write(filepath.Join(file.Directory, file.Filename), tt.sidecar); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if got := templateData(logger, file, "Seattle"); !maps.Equal(got, tt.want) {
+				t.Errorf("templateData() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUploadedVideosQuotaExceeded(t *testing.T) {
+	quotaExceeded := `{"error": {"code": 403, "message": "quota", "errors": [{"reason": "quotaExceeded"}]}}`
+	searchResult := `{"items": [{"id": {"kind": "youtube#video", "videoId": "abc123"}}]}`
+
+	tests := []struct {
+		name   string
+		search string // response body of search.list, empty means quota exceeded
+	}{
+		{name: "search.list", search: ""},
+		{name: "videos.list", search: searchResult},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if r.URL.Path == "/youtube/v3/search" && tt.search != "" {
+					io.WriteString(w, tt.search)
+					return
+				}
+				w.WriteHeader(http.StatusForbidden)
+				io.WriteString(w, quotaExceeded)
+			}))
+			defer srv.Close()
+
+			service, err := youtube.NewService(context.Background(), option.WithHTTPClient(srv.Client()), option.WithEndpoint(srv.URL))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ledger, err := ytquota.Load(filepath.Join(t.TempDir(), "quota.json"), 10000)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = getUploadedVideos(service, ledger)
+			if !ytquota.IsQuotaExceeded(err) {
+				t.Errorf("getUploadedVideos() error = %v, want quota exceeded", err)
+			}
+		})
+	}
+}
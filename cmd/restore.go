@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+
+	"github.com/EarthmanMuons/herosync/internal/fsutil"
+	"github.com/EarthmanMuons/herosync/internal/trash"
+)
+
+// newRestoreCmd constructs the "restore" subcommand.
+func newRestoreCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "restore [FILENAME]...",
+		Short: "Recover deleted media files from the trash",
+		Long: `Recover deleted media files from the trash.
+
+Local media files removed by "cleanup --local", by "combine", or by a retention
+policy are held in the trash until they are purged after the configured maximum
+age, or sooner when the oldest are purged to free up space for new media.
+
+Without arguments, the contents of the trash are listed. If one or more
+[FILENAME] arguments are provided, matching files are moved back to their
+original location.`,
+		Args: cobra.ArbitraryArgs,
+		RunE: runRestore,
+	}
+}
+
+// runRestore is the entry point for the "restore" subcommand.
+func runRestore(cmd *cobra.Command, args []string) error {
+	_, logger, cfg, err := contextLoggerConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	dryRun := isDryRun(cmd)
+
+	bin, err := openTrash(logger, cfg, dryRun)
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		listTrash(bin)
+		return nil
+	}
+
+	var matched []*trash.Item
+	for _, item := range bin.Items {
+		if matchesAnyKeyword(item.Filename(), args) {
+			matched = append(matched, item)
+		}
+	}
+
+	if len(matched) == 0 {
+		return fmt.Errorf("no matching files found in trash for: %v", args)
+	}
+
+	for _, item := range matched {
+		if dryRun {
+			printPlan("restore %s to %s", item.Filename(), fsutil.ShortenPath(item.OriginalPath))
+			continue
+		}
+
+		if err := bin.Restore(item); err != nil {
+			logger.Error("restore failed", slog.String("filename", item.Filename()), slog.Any("error", err))
+			continue
+		}
+		logger.Info("file restored", slog.String("path", item.OriginalPath))
+	}
+
+	return nil
+}
+
+// listTrash prints the contents of the trash.
+func listTrash(bin *trash.Bin) {
+	if len(bin.Items) == 0 {
+		fmt.Println("Trash is empty.")
+		return
+	}
+
+	for _, item := range bin.Items {
+		fmt.Printf("%8s  %20s  %s  (%s)\n",
+			humanize.Bytes(uint64(item.Size)),
+			item.TrashedAt.Format(time.DateTime),
+			item.Filename(),
+			item.Reason,
+		)
+	}
+}
@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/EarthmanMuons/herosync/config"
+	"github.com/EarthmanMuons/herosync/internal/fsutil"
+	"github.com/EarthmanMuons/herosync/internal/media"
+	"github.com/EarthmanMuons/herosync/internal/pubrecord"
+	"github.com/EarthmanMuons/herosync/internal/sidecar"
+)
+
+// newRetimeCmd constructs the "retime" subcommand.
+func newRetimeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "retime --offset DURATION FILENAME...",
+		Short: "Shift the timestamps of local media files",
+		Long: `Shift the timestamps of local media files.
+
+Use this to correct media recorded while the GoPro's clock was wrong. Incoming
+files have their modification time shifted; outgoing files also have their MP4
+creation time rewritten, and date-grouped videos are renamed to match.
+
+The offset is applied each time the command runs. For media still on the GoPro,
+add a [[retime]] entry to the config file instead, which corrects the times
+reported by the camera:
+
+  [[retime]]
+  media-id = 42       # or: date = "2025-03-01"
+  offset = "+1h30m"`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: runRetime,
+	}
+
+	cmd.Flags().Duration("offset", 0, "amount to shift the timestamps by (e.g., 1h30m, -45s)")
+	cmd.MarkFlagRequired("offset")
+
+	return cmd
+}
+
+// runRetime is the entry point for the "retime" subcommand.
+func runRetime(cmd *cobra.Command, args []string) error {
+	ctx, logger, cfg, err := contextLoggerConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	offset, _ := cmd.Flags().GetDuration("offset")
+	if offset == 0 {
+		return fmt.Errorf("offset must not be zero")
+	}
+
+	dirs, err := incomingDirs(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	inventory, err := media.NewLocalInventory(ctx, dirs, cfg.OutgoingMediaDir())
+	if err != nil {
+		return err
+	}
+
+	inventory, err = inventory.FilterByDisplayInfo(args)
+	if err != nil {
+		return err
+	}
+
+	record, err := pubrecord.Load(defaultPublicationRecordPath())
+	if err != nil {
+		return err
+	}
+
+	ingest, err := media.LoadIngestLog(cfg.OutgoingMediaDir())
+	if err != nil {
+		return err
+	}
+
+	dryRun := isDryRun(cmd)
+
+	for _, file := range inventory.Files {
+		path := filepath.Join(file.Directory, file.Filename)
+		corrected := file.CreatedAt.Add(offset)
+
+		if dryRun {
+			printPlan("retime %s from %s to %s", fsutil.ShortenPath(path),
+				file.CreatedAt.Format(time.DateTime), corrected.Format(time.DateTime))
+			continue
+		}
+
+		if err := retimeFile(ctx, logger, file, corrected, record, ingest); err != nil {
+			logger.Error("failed to retime file", slog.String("filename", file.Filename), slog.Any("error", err))
+			continue
+		}
+	}
+
+	return nil
+}
+
+// timeCorrections returns the creation time corrections from the [[retime]]
+// config table, applied to camera media as it's listed.
+func timeCorrections(cfg *config.Config) media.TimeCorrections {
+	corrections := make(media.TimeCorrections, 0, len(cfg.Retime))
+	for _, r := range cfg.Retime {
+		corrections = append(corrections, media.TimeCorrection{
+			MediaID: r.MediaID,
+			Date:    r.Date,
+			Offset:  r.Offset,
+		})
+	}
+	return corrections
+}
+
+// retimeFile moves the timestamps of a local file to the corrected time.
+// Outgoing files keep their publication record and ingest log entries.
+func retimeFile(ctx context.Context, logger *slog.Logger, file media.File, corrected time.Time, record *pubrecord.Record, ingest *media.IngestLog) error {
+	path := filepath.Join(file.Directory, file.Filename)
+
+	if file.Status == media.Processed {
+		if err := writeCreationTime(ctx, logger, path, corrected); err != nil {
+			return err
+		}
+
+		renamed, err := renameForDate(path, corrected)
+		if err != nil {
+			return err
+		}
+		path = renamed
+
+		if err := retimeSidecar(path, corrected); err != nil {
+			return err
+		}
+
+		if err := followRetimedFile(record, ingest, file, path); err != nil {
+			return err
+		}
+	}
+
+	if err := fsutil.SetMtime(logger, path, corrected); err != nil {
+		return err
+	}
+
+	logger.Info("file retimed", slog.String("path", fsutil.ShortenPath(path)), slog.Time("created-at", corrected))
+	return nil
+}
+
+// writeCreationTime rewrites the container and stream creation times of the
+// MP4 file at path, keeping all of its streams.
+func writeCreationTime(ctx context.Context, logger *slog.Logger, path string, t time.Time) error {
+	streams, err := media.ProbeStreams(ctx, path)
+	if err != nil {
+		return err
+	}
+
+	ext := filepath.Ext(path)
+	tmpPath := strings.TrimSuffix(path, ext) + ".retime" + ext
+	creationTime := creationTimeTag(t)
+
+	args := []string{"-y", "-i", path}
+	args = append(args, trackMappingArgs(streams)...)
+	args = append(args, "-movflags", "+use_metadata_tags", "-metadata", creationTime, "-metadata:s", creationTime, "-c", "copy", tmpPath)
+
+	if err := execFFmpeg(ctx, args, logger); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("replacing file: %w", err)
+	}
+	return nil
+}
+
+// retimeSidecar updates the recording time in the video's sidecar, if any.
+func retimeSidecar(videoPath string, t time.Time) error {
+	s, err := sidecar.Read(videoPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	s.CreatedAt = t
+	return sidecar.Write(videoPath, s)
+}
+
+// followRetimedFile points the publication record and ingest log entries of
+// an outgoing file at its retimed version, now at path, whose name and size
+// may have changed.
+func followRetimedFile(record *pubrecord.Record, ingest *media.IngestLog, file media.File, path string) error {
+	newName := filepath.Base(path)
+	if newName != file.Filename {
+		if err := ingest.Rename(file.Filename, newName); err != nil {
+			return err
+		}
+	}
+
+	// Leave entries that describe an earlier file of the same name alone.
+	entry, ok := record.Get(file.Filename)
+	if !ok || entry.Size != file.Size {
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	if newName != file.Filename {
+		if err := record.Rename(file.Filename, newName); err != nil {
+			return err
+		}
+	}
+	entry.Size = info.Size()
+	return record.Put(entry)
+}
+
+// renameForDate renames a date-grouped video so that its name matches the
+// corrected date, returning the new path.
+func renameForDate(path string, t time.Time) (string, error) {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(filepath.Base(path), ext)
+	base = counterRe.ReplaceAllString(base, "")
+
+	match := dateRe.FindStringSubmatch(base)
+	if match == nil || match[1] == t.Format(time.DateOnly) {
+		return path, nil
+	}
+
+	name := fmt.Sprintf("daily-%s%s", t.Format(time.DateOnly), ext)
+	newPath, err := fsutil.GenerateUniqueFilename(filepath.Join(filepath.Dir(path), name))
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.Rename(path, newPath); err != nil {
+		return "", fmt.Errorf("renaming file: %w", err)
+	}
+	if err := moveCompanionFiles(path, newPath); err != nil {
+		return "", err
+	}
+	return newPath, nil
+}
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/EarthmanMuons/herosync/internal/media"
+	"github.com/EarthmanMuons/herosync/internal/pubrecord"
+)
+
+func TestRenameForDate(t *testing.T) {
+	corrected := time.Date(2025, 6, 2, 0, 30, 0, 0, time.Local)
+
+	tests := []struct {
+		name     string
+		filename string
+		files    []string // other files in the directory
+		want     []string
+	}{
+		{
+			name:     "date group moves with its companions",
+			filename: "daily-2025-06-01.mp4",
+			files:    []string{"daily-2025-06-01.herosync.json", "daily-2025-06-01.gpx", "daily-2025-06-01.gps.csv"},
+			want:     []string{"daily-2025-06-02.gps.csv", "daily-2025-06-02.gpx", "daily-2025-06-02.herosync.json", "daily-2025-06-02.mp4"},
+		},
+		{
+			name:     "counter suffix dropped",
+			filename: "daily-2025-06-01_1.mp4",
+			want:     []string{"daily-2025-06-02.mp4"},
+		},
+		{
+			name:     "taken name gets a counter",
+			filename: "daily-2025-06-01.mp4",
+			files:    []string{"daily-2025-06-02.mp4"},
+			want:     []string{"daily-2025-06-02.mp4", "daily-2025-06-02_1.mp4"},
+		},
+		{
+			name:     "date already correct",
+			filename: "daily-2025-06-02.mp4",
+			want:     []string{"daily-2025-06-02.mp4"},
+		},
+		{
+			name:     "not date grouped",
+			filename: "gopro-42.mp4",
+			files:    []string{"gopro-42.gpx"},
+			want:     []string{"gopro-42.gpx", "gopro-42.mp4"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, append(tt.files, tt.filename)...)
+
+			if _, err := renameForDate(filepath.Join(dir, tt.filename), corrected); err != nil {
+				t.Fatalf("renameForDate() error = %v", err)
+			}
+			if got := listFiles(t, dir); !slices.Equal(got, tt.want) {
+				t.Errorf("files = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFollowRetimedFile(t *testing.T) {
+	const oldName, newName = "daily-2025-06-01.mp4", "daily-2025-06-02.mp4"
+
+	tests := []struct {
+		name       string
+		entrySize  int64  // size recorded when the file was published, 0 for none
+		newPath    string // name of the retimed file
+		wantRecord map[string]int64
+		wantIngest []string
+	}{
+		{
+			name:       "renamed published file",
+			entrySize:  10,
+			newPath:    newName,
+			wantRecord: map[string]int64{newName: 12},
+			wantIngest: []string{newName},
+		},
+		{
+			name:       "published file kept its name",
+			entrySize:  10,
+			newPath:    oldName,
+			wantRecord: map[string]int64{oldName: 12},
+			wantIngest: []string{oldName},
+		},
+		{
+			name:       "record of an earlier file",
+			entrySize:  99,
+			newPath:    newName,
+			wantRecord: map[string]int64{oldName: 99},
+			wantIngest: []string{newName},
+		},
+		{
+			name:       "unpublished file",
+			newPath:    newName,
+			wantRecord: map[string]int64{},
+			wantIngest: []string{newName},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, tt.newPath)
+			if err := os.WriteFile(path, make([]byte, 12), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			record, err := pubrecord.Load(filepath.Join(dir, "state", "published.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.entrySize != 0 {
+				if err := record.Put(&pubrecord.Entry{Filename: oldName, Size: tt.entrySize, State: pubrecord.Processed}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ingest, err := media.LoadIngestLog(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := ingest.MarkIngested(oldName); err != nil {
+				t.Fatal(err)
+			}
+
+			file := media.File{Directory: dir, Filename: oldName, Size: 10}
+			if err := followRetimedFile(record, ingest, file, path); err != nil {
+				t.Fatalf("followRetimedFile() error = %v", err)
+			}
+
+			gotRecord := make(map[string]int64)
+			for name, entry := range record.Entries {
+				if entry.Filename != name {
+					t.Errorf("entry %q has filename %q", name, entry.Filename)
+				}
+				gotRecord[name] = entry.Size
+			}
+			if !maps.Equal(gotRecord, tt.wantRecord) {
+				t.Errorf("record = %v, want %v", gotRecord, tt.wantRecord)
+			}
+
+			// Check what was persisted rather than the in-memory log.
+			ingest, err = media.LoadIngestLog(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var gotIngest []string
+			for name := range ingest.Files {
+				gotIngest = append(gotIngest, name)
+			}
+			if !slices.Equal(gotIngest, tt.wantIngest) {
+				t.Errorf("ingest log = %v, want %v", gotIngest, tt.wantIngest)
+			}
+		})
+	}
+}
@@ -1,11 +1,16 @@
 package cmd
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -14,6 +19,9 @@ import (
 	"github.com/EarthmanMuons/herosync/internal/fsutil"
 	"github.com/EarthmanMuons/herosync/internal/gopro"
 	"github.com/EarthmanMuons/herosync/internal/media"
+	"github.com/EarthmanMuons/herosync/internal/pubrecord"
+	"github.com/EarthmanMuons/herosync/internal/sidecar"
+	"github.com/EarthmanMuons/herosync/internal/trash"
 )
 
 // NewRootCmd constructs the root command.
@@ -35,11 +43,19 @@ func NewRootCmd() *cobra.Command {
 
 	cobra.EnableCommandSorting = false
 	rootCmd.AddCommand(newStatusCmd())
+	rootCmd.AddCommand(newDiscoverCmd())
 	rootCmd.AddCommand(newListCmd())
 	rootCmd.AddCommand(newDownloadCmd())
 	rootCmd.AddCommand(newCombineCmd())
 	rootCmd.AddCommand(newPublishCmd())
+	rootCmd.AddCommand(newTelemetryCmd())
 	rootCmd.AddCommand(newCleanupCmd())
+	rootCmd.AddCommand(newRestoreCmd())
+	rootCmd.AddCommand(newRetimeCmd())
+	rootCmd.AddCommand(newClockCmd())
+	rootCmd.AddCommand(newSettingsCmd())
+	rootCmd.AddCommand(newCameraCmd())
+	rootCmd.AddCommand(newCOHNCmd())
 	rootCmd.AddCommand(newYOLOCmd())
 
 	addGlobalFlags(rootCmd)
@@ -54,6 +70,9 @@ const (
 	configFileUsage = `configuration file path
 [env: HEROSYNC_CONFIG_FILE]
 [default: %s]
+`
+	cameraUsage = `names of the camera profiles to use, comma-separated
+[default: all configured cameras]
 `
 	goproHostUsage = `GoPro URL host (IP, hostname:port, "" for mDNS discovery)
 [env: HEROSYNC_GOPRO_HOST]
@@ -62,6 +81,8 @@ const (
 	goproSchemeUsage = `GoPro URL scheme (http, https)
 [env: HEROSYNC_GOPRO_SCHEME]
 [default: http]
+`
+	dryRunUsage = `print the planned actions without changing anything
 `
 	helpUsage = `help for herosync
 `
@@ -80,7 +101,9 @@ func addGlobalFlags(rootCmd *cobra.Command) {
 	defaultConfig := fsutil.ShortenPath(config.DefaultConfigPath())
 	defaultMedia := fsutil.ShortenPath(config.DefaultMediaDir())
 
+	rootCmd.PersistentFlags().StringSlice("camera", nil, cameraUsage)
 	rootCmd.PersistentFlags().StringP("config-file", "c", "", fmt.Sprintf(configFileUsage, defaultConfig))
+	rootCmd.PersistentFlags().BoolP("dry-run", "n", false, dryRunUsage)
 	rootCmd.PersistentFlags().String("gopro-host", "", goproHostUsage)
 	rootCmd.PersistentFlags().String("gopro-scheme", "", goproSchemeUsage)
 	rootCmd.PersistentFlags().BoolP("help", "h", false, helpUsage)
@@ -116,7 +139,7 @@ func initConfig(cmd *cobra.Command) {
 	// 2. Config file
 	// 3. Env vars
 	// 4. Global CLI flags (subcommand flags are NOT available yet)
-	if err := config.Init(path, flags); err != nil {
+	if err := config.Init(path, requestPolicyDefaults(), flags); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -136,6 +159,9 @@ func configFile(cmd *cobra.Command) (path string) {
 func collectFlagOverrides(cmd *cobra.Command) map[string]any {
 	flags := make(map[string]any)
 	cmd.Flags().Visit(func(f *pflag.Flag) {
+		if f.Name == "camera" {
+			return // selects camera profiles rather than overriding a setting
+		}
 		flags[f.Name] = f.Value.String()
 	})
 	return flags
@@ -172,8 +198,144 @@ func contextLoggerConfig(cmd *cobra.Command) (context.Context, *slog.Logger, *co
 	return ctx, logger, cfg, nil
 }
 
-func loadFilteredInventory(ctx context.Context, cfg *config.Config, client *gopro.Client, keywords []string) (*media.Inventory, error) {
-	inventory, err := media.NewInventory(ctx, client, cfg.IncomingMediaDir(), cfg.OutgoingMediaDir())
+// selectedCameras returns the camera profiles chosen with --camera, or all of
+// them if the flag wasn't given.
+func selectedCameras(cmd *cobra.Command, cfg *config.Config) ([]config.Camera, error) {
+	// Camera profiles carry their own hosts, so --gopro-host would be ignored.
+	if cmd.Flags().Changed("gopro-host") && len(cfg.CameraProfiles) > 0 {
+		return nil, errors.New("--gopro-host can't be used with [cameras] profiles; set the host in the profile and select it with --camera")
+	}
+
+	names, _ := cmd.Flags().GetStringSlice("camera")
+	return cfg.SelectCameras(names)
+}
+
+// incomingDirs returns the incoming directories of the selected cameras.
+func incomingDirs(cmd *cobra.Command, cfg *config.Config) ([]string, error) {
+	cameras, err := selectedCameras(cmd, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	dirs := make([]string, len(cameras))
+	for i, cam := range cameras {
+		dirs[i] = cam.IncomingDir
+	}
+	return dirs, nil
+}
+
+// newCameraClient connects to the camera, discovering it by serial number when
+// the profile has no host. The extra options are applied after the configured
+// ones.
+func newCameraClient(ctx context.Context, logger *slog.Logger, cfg *config.Config, cam config.Camera, extra ...gopro.Option) (*gopro.Client, error) {
+	var opts []gopro.Option
+	for op, policy := range requestPolicies(cfg) {
+		opts = append(opts, gopro.WithPolicy(op, policy))
+	}
+	if cam.Username != "" {
+		opts = append(opts, gopro.WithBasicAuth(cam.Username, cam.Password))
+	}
+	if cam.CACert != "" {
+		opt, err := gopro.WithCACert(cam.CACert)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, opt)
+	}
+	if cam.Fingerprint != "" {
+		fp, err := gopro.ParseFingerprint(cam.Fingerprint)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, gopro.WithPinnedCert(fp))
+	}
+	opts = append(opts, extra...)
+
+	if cam.Host == "" && cam.Serial != "" {
+		return gopro.NewClientForSerial(ctx, logger, cam.Scheme, cam.Serial, opts...)
+	}
+	if cam.Serial != "" {
+		opts = append(opts, gopro.WithSerial(cam.Serial))
+	}
+	return gopro.NewClient(logger, cam.Scheme, cam.Host, opts...)
+}
+
+// requestPolicies returns the configured policy for each kind of GoPro request.
+func requestPolicies(cfg *config.Config) map[gopro.Operation]gopro.RequestPolicy {
+	return map[gopro.Operation]gopro.RequestPolicy{
+		gopro.OpQuery:    gopro.RequestPolicy(cfg.HTTP.Query),
+		gopro.OpDownload: gopro.RequestPolicy(cfg.HTTP.Download),
+		gopro.OpDelete:   gopro.RequestPolicy(cfg.HTTP.Delete),
+		gopro.OpControl:  gopro.RequestPolicy(cfg.HTTP.Control),
+	}
+}
+
+// requestPolicyDefaults returns the GoPro client's default request policies
+// as "http.*" configuration defaults, so that they're defined in one place.
+// Unset fields are left out, which keeps them at zero.
+func requestPolicyDefaults() map[string]any {
+	defaults := make(map[string]any)
+	for op, p := range gopro.DefaultPolicies() {
+		prefix := "http." + op.String() + "."
+		durations := map[string]time.Duration{
+			"timeout":      p.Timeout,
+			"idle-timeout": p.IdleTimeout,
+			"backoff-min":  p.BackoffMin,
+			"backoff-max":  p.BackoffMax,
+		}
+		for key, d := range durations {
+			if d != 0 {
+				defaults[prefix+key] = d.String()
+			}
+		}
+		if p.Retries != 0 {
+			defaults[prefix+"retries"] = p.Retries
+		}
+	}
+	return defaults
+}
+
+// forEachCamera connects to each selected camera in turn and calls fn with a
+// logger tagged with the camera's name. A failing camera doesn't stop the
+// others; all errors are returned together. The given client options, such as
+// a shared rate limiter, apply to every camera's client.
+func forEachCamera(cmd *cobra.Command, cfg *config.Config, fn func(logger *slog.Logger, cam config.Camera, client *gopro.Client) error, opts ...gopro.Option) error {
+	ctx, logger := cmd.Context(), slog.Default()
+
+	cameras, err := selectedCameras(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, cam := range cameras {
+		camLogger := logger
+		if cam.Name != "" {
+			camLogger = logger.With(slog.String("camera", cam.Name))
+		}
+
+		err := func() error {
+			client, err := newCameraClient(ctx, camLogger, cfg, cam, opts...)
+			if err != nil {
+				return err
+			}
+			return fn(camLogger, cam, client)
+		}()
+		if err == nil {
+			continue
+		}
+
+		if cam.Name != "" {
+			err = fmt.Errorf("camera %s: %w", cam.Name, err)
+		}
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+func loadFilteredInventory(ctx context.Context, cfg *config.Config, client *gopro.Client, incomingDir string, keywords []string) (*media.Inventory, error) {
+	inventory, err := media.NewInventory(ctx, client, incomingDir, cfg.OutgoingMediaDir(), timeCorrections(cfg))
 	if err != nil {
 		return nil, err
 	}
@@ -188,3 +350,280 @@ func loadFilteredInventory(ctx context.Context, cfg *config.Config, client *gopr
 
 	return inventory, nil
 }
+
+// isDryRun reports whether the --dry-run flag was given.
+func isDryRun(cmd *cobra.Command) bool {
+	enabled, _ := cmd.Flags().GetBool("dry-run")
+	return enabled
+}
+
+// printPlan reports an action that would be taken if this weren't a dry run.
+func printPlan(format string, args ...any) {
+	fmt.Printf("[dry-run] "+format+"\n", args...)
+}
+
+// openTrash opens the trash directory, purging files that have been held longer
+// than the configured maximum age.
+func openTrash(logger *slog.Logger, cfg *config.Config, dryRun bool) (*trash.Bin, error) {
+	bin, err := trash.Open(cfg.TrashDir())
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Trash.MaxAge == 0 {
+		return bin, nil
+	}
+	maxAge := time.Duration(cfg.Trash.MaxAge) * 24 * time.Hour
+
+	if dryRun {
+		for _, item := range bin.Expired(maxAge) {
+			printPlan("purge %s from trash", item.Filename())
+		}
+		return bin, nil
+	}
+
+	purged, err := bin.Purge(maxAge)
+	for _, item := range purged {
+		logger.Info("purged file from trash", slog.String("filename", item.Filename()), slog.Time("trashed-at", item.TrashedAt))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return bin, nil
+}
+
+// stdinIsTerminal reports whether standard input is an interactive terminal.
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// confirm asks the user a yes/no question, defaulting to no.
+func confirm(prompt string) bool {
+	fmt.Printf("%s [y/N] ", prompt)
+
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+
+	return answer == "y" || answer == "yes"
+}
+
+// enforceRetention moves media files exceeding the configured retention
+// policies to the trash, covering the incoming directories of the selected
+// cameras and the outgoing directory. Incoming files are only deleted once
+// they've been combined, and outgoing files once their publication has been
+// confirmed, so that nothing is lost that doesn't exist elsewhere.
+func enforceRetention(cmd *cobra.Command, logger *slog.Logger, cfg *config.Config) error {
+	const day = 24 * time.Hour
+	dryRun := isDryRun(cmd)
+
+	incoming, err := incomingDirs(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	record, err := pubrecord.Load(defaultPublicationRecordPath())
+	if err != nil {
+		return err
+	}
+
+	bin, err := trash.Open(cfg.TrashDir())
+	if err != nil {
+		return err
+	}
+
+	type retentionDir struct {
+		path      string
+		policy    media.RetentionPolicy
+		deletable func(log *media.IngestLog, name string, info os.FileInfo) bool
+	}
+
+	combined := func(log *media.IngestLog, name string, _ os.FileInfo) bool {
+		entry, ok := log.Get(name)
+		return ok && entry.Combined()
+	}
+	published := func(_ *media.IngestLog, name string, info os.FileInfo) bool {
+		entry, ok := record.Get(name)
+		return ok && entry.Confirmed() && entry.Matches(info)
+	}
+
+	// Each selected camera's incoming directory is held to the incoming policy.
+	var dirs []retentionDir
+	for _, dir := range incoming {
+		dirs = append(dirs, retentionDir{dir, media.RetentionPolicy{
+			MaxSize: uint64(cfg.Retention.IncomingMaxSize),
+			MaxAge:  time.Duration(cfg.Retention.IncomingMaxAge) * day,
+		}, combined})
+	}
+	dirs = append(dirs, retentionDir{cfg.OutgoingMediaDir(), media.RetentionPolicy{
+		MaxSize: uint64(cfg.Retention.OutgoingMaxSize),
+		MaxAge:  time.Duration(cfg.Retention.OutgoingMaxAge) * day,
+	}, published})
+
+	for _, d := range dirs {
+		log, err := media.LoadIngestLog(d.path)
+		if err != nil {
+			return err
+		}
+
+		// Start aging files that arrived before the ingest log existed.
+		if !dryRun {
+			if err := log.Sync(); err != nil {
+				return fmt.Errorf("updating ingest log: %w", err)
+			}
+		}
+
+		planned, err := media.PlanRetention(d.path, d.policy, log, func(name string, info os.FileInfo) bool {
+			return d.deletable(log, name, info)
+		})
+		if err != nil {
+			return fmt.Errorf("planning retention: %w", err)
+		}
+
+		for _, filename := range planned {
+			path := filepath.Join(d.path, filename)
+			if dryRun {
+				printPlan("move %s to trash (retention policy)", filepath.Join(fsutil.ShortenPath(d.path), filename))
+				continue
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				return fmt.Errorf("enforcing retention: %w", err)
+			}
+			if err := trashMediaFile(bin, path, "retention policy"); err != nil {
+				return fmt.Errorf("enforcing retention: %w", err)
+			}
+			// Keep the file from being downloaded again while it's still on the camera.
+			if err := log.MarkRetired(filename, info.Size()); err != nil {
+				return fmt.Errorf("enforcing retention: %w", err)
+			}
+			logger.Info("retention policy moved file to trash", slog.String("dir", fsutil.ShortenPath(d.path)), slog.String("filename", filename))
+		}
+	}
+
+	return nil
+}
+
+// trashMediaFile moves the video at path to the trash along with its metadata
+// and telemetry sidecars.
+func trashMediaFile(bin *trash.Bin, path, reason string) error {
+	companions := companionFiles(path)
+	if _, err := bin.Move(path, reason); err != nil {
+		return err
+	}
+	for _, companion := range companions {
+		if _, err := bin.Move(companion, reason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// companionFiles returns the existing sidecar files of the video at path.
+func companionFiles(path string) []string {
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+
+	candidates := []string{sidecar.Path(path)}
+	for _, suffix := range telemetrySidecarSuffixes {
+		candidates = append(candidates, base+suffix)
+	}
+
+	var existing []string
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			existing = append(existing, candidate)
+		}
+	}
+	return existing
+}
+
+// moveCompanionFiles moves the sidecar files of the video at oldPath so they
+// follow the video to newPath.
+func moveCompanionFiles(oldPath, newPath string) error {
+	oldBase := strings.TrimSuffix(oldPath, filepath.Ext(oldPath))
+	newBase := strings.TrimSuffix(newPath, filepath.Ext(newPath))
+
+	for _, companion := range companionFiles(oldPath) {
+		if err := fsutil.MoveFile(companion, newBase+strings.TrimPrefix(companion, oldBase)); err != nil {
+			return fmt.Errorf("moving sidecar: %w", err)
+		}
+	}
+	return nil
+}
+
+// removeCompanionFiles deletes the sidecar files of the video at path.
+func removeCompanionFiles(path string) error {
+	for _, companion := range companionFiles(path) {
+		if err := os.Remove(companion); err != nil {
+			return fmt.Errorf("removing sidecar: %w", err)
+		}
+	}
+	return nil
+}
+
+// ensureFreeSpace verifies that dir has room for the required bytes plus the
+// configured headroom, purging the oldest files from the trash to make room
+// if it shares dir's filesystem. Platforms without free space reporting skip
+// the check, and a dry run only warns about the shortfall.
+func ensureFreeSpace(logger *slog.Logger, cfg *config.Config, dir string, required int64, dryRun bool) error {
+	check := func() error {
+		return fsutil.EnsureFreeSpace(dir, uint64(max(required, 0)), uint64(cfg.Media.MinFree))
+	}
+
+	err := check()
+	if errors.Is(err, errors.ErrUnsupported) {
+		logger.Debug("skipping free space check", slog.Any("error", err))
+		return nil
+	}
+	if err == nil {
+		return nil
+	}
+	if dryRun {
+		logger.Warn("not enough free space to carry out the plan", slog.Any("error", err))
+		return nil
+	}
+
+	if same, sameErr := fsutil.SameFilesystem(dir, cfg.TrashDir()); sameErr != nil || !same {
+		return err
+	}
+
+	bin, openErr := trash.Open(cfg.TrashDir())
+	if openErr != nil {
+		return errors.Join(err, openErr)
+	}
+	for err != nil {
+		item, purgeErr := bin.PurgeOldest()
+		if purgeErr != nil {
+			return errors.Join(err, purgeErr)
+		}
+		if item == nil {
+			return err // the trash is empty
+		}
+		logger.Info("purged file from trash to free space", slog.String("filename", item.Filename()), slog.Time("trashed-at", item.TrashedAt))
+		err = check()
+	}
+	return nil
+}
+
+// ensureCameraReady waits for the camera to finish whatever it's busy with and
+// refuses to continue if its battery is too low to finish a transfer.
+func ensureCameraReady(ctx context.Context, logger *slog.Logger, cfg *config.Config, client *gopro.Client) error {
+	state, err := client.WaitUntilReady(ctx, cfg.Transfer.ReadyTimeout, 5*time.Second)
+	if err != nil {
+		return err
+	}
+
+	status := state.Status
+	if status.SystemHot || status.TooCold {
+		logger.Warn("camera temperature out of range", slog.Bool("hot", status.SystemHot), slog.Bool("cold", status.TooCold))
+	}
+
+	if !status.ExternalPower() && status.BatteryPercent < cfg.Transfer.MinBattery {
+		return fmt.Errorf("camera battery at %d%% is below the %d%% minimum; connect external power or lower transfer.min-battery",
+			status.BatteryPercent, cfg.Transfer.MinBattery)
+	}
+
+	return nil
+}
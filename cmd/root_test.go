@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// writeFiles creates empty files with the given names in dir.
+func writeFiles(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// listFiles returns the sorted names of the files in dir.
+func listFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestCompanionFiles(t *testing.T) {
+	companions := []string{
+		"gopro-42.herosync.json", "gopro-42.gpx", "gopro-42.geojson", "gopro-42.gps.csv",
+		"gopro-42.accl.csv", "gopro-42.gyro.csv", "gopro-42.tmpc.csv",
+	}
+
+	tests := []struct {
+		name     string
+		files    []string
+		wantSrc  []string
+		wantDst  []string // nil means removing the companions instead
+		dstVideo string
+	}{
+		{
+			name:     "move every companion",
+			files:    companions,
+			dstVideo: "gopro-42_1.mp4",
+			wantDst: []string{
+				"gopro-42_1.accl.csv", "gopro-42_1.geojson", "gopro-42_1.gps.csv", "gopro-42_1.gpx",
+				"gopro-42_1.gyro.csv", "gopro-42_1.herosync.json", "gopro-42_1.tmpc.csv",
+			},
+		},
+		{
+			name:     "move only existing companions",
+			files:    []string{"gopro-42.herosync.json", "gopro-42.gpx"},
+			dstVideo: "gopro-42.mp4",
+			wantDst:  []string{"gopro-42.gpx", "gopro-42.herosync.json"},
+		},
+		{
+			name:    "remove every companion",
+			files:   append([]string{"gopro-42.mp4", "gopro-421.gpx", "gopro-42.txt"}, companions...),
+			wantSrc: []string{"gopro-42.mp4", "gopro-42.txt", "gopro-421.gpx"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, dst := t.TempDir(), t.TempDir()
+			writeFiles(t, src, tt.files...)
+			video := filepath.Join(src, "gopro-42.mp4")
+
+			if tt.wantDst != nil {
+				if err := moveCompanionFiles(video, filepath.Join(dst, tt.dstVideo)); err != nil {
+					t.Fatalf("moveCompanionFiles() error = %v", err)
+				}
+			} else if err := removeCompanionFiles(video); err != nil {
+				t.Fatalf("removeCompanionFiles() error = %v", err)
+			}
+
+			if got := listFiles(t, src); !slices.Equal(got, tt.wantSrc) {
+				t.Errorf("source files = %v, want %v", got, tt.wantSrc)
+			}
+			if got := listFiles(t, dst); !slices.Equal(got, tt.wantDst) {
+				t.Errorf("destination files = %v, want %v", got, tt.wantDst)
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/EarthmanMuons/herosync/config"
+	"github.com/EarthmanMuons/herosync/internal/gopro"
+)
+
+// newSettingsCmd constructs the "settings" subcommand.
+func newSettingsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "settings",
+		Short: "View or change the GoPro's capture settings",
+		Long: `View or change the GoPro's capture settings.
+
+Known settings are resolution, fps, lens, hypersmooth, auto-power-down, and
+bit-rate. Options are matched by name (e.g., "4K", "60", "Linear"); prefix a
+number with "#" to use a raw option value.
+
+To keep cameras set up the same way before a shoot, declare the desired
+settings in the config file and run "herosync settings apply":
+
+  [settings]
+  resolution = "4K"
+  fps = "60"
+  lens = "Linear"`,
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "get [SETTING]...",
+		Short: "Show the current settings",
+		Args:  cobra.ArbitraryArgs,
+		RunE:  runSettingsGet,
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "set SETTING=OPTION...",
+		Short: "Change one or more settings",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  runSettingsSet,
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "diff",
+		Short: "Compare the current settings with the configured profile",
+		Args:  cobra.NoArgs,
+		RunE:  runSettingsDiff,
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "apply",
+		Short: "Change the settings that differ from the configured profile",
+		Args:  cobra.NoArgs,
+		RunE:  runSettingsApply,
+	})
+
+	return cmd
+}
+
+// runSettingsGet is the entry point for the "settings get" subcommand.
+func runSettingsGet(cmd *cobra.Command, args []string) error {
+	ctx, _, cfg, err := contextLoggerConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	settings := gopro.KnownSettings
+	if len(args) > 0 {
+		settings = nil
+		for _, key := range args {
+			setting, err := gopro.LookupSetting(key)
+			if err != nil {
+				return err
+			}
+			settings = append(settings, setting)
+		}
+	}
+
+	return forEachCamera(cmd, cfg, func(logger *slog.Logger, cam config.Camera, client *gopro.Client) error {
+		state, err := client.GetCameraState(ctx)
+		if err != nil {
+			return err
+		}
+
+		if cam.Name != "" {
+			fmt.Printf("== %s ==\n", cam.Name)
+		}
+		for _, setting := range settings {
+			current := "not reported"
+			if value, ok := state.Setting(setting.ID); ok {
+				current = setting.OptionName(value)
+			}
+			fmt.Printf("%-16s %s\n", setting.Key+":", current)
+		}
+
+		return nil
+	})
+}
+
+// runSettingsSet is the entry point for the "settings set" subcommand.
+func runSettingsSet(cmd *cobra.Command, args []string) error {
+	ctx, _, cfg, err := contextLoggerConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	var changes []gopro.SettingValue
+	for _, arg := range args {
+		key, name, ok := strings.Cut(arg, "=")
+		if !ok {
+			return fmt.Errorf("invalid setting %q (use SETTING=OPTION)", arg)
+		}
+		setting, err := gopro.LookupSetting(key)
+		if err != nil {
+			return err
+		}
+		option, err := setting.ParseOption(name)
+		if err != nil {
+			return err
+		}
+		changes = append(changes, gopro.SettingValue{Setting: setting, Option: option})
+	}
+
+	return forEachCamera(cmd, cfg, func(logger *slog.Logger, cam config.Camera, client *gopro.Client) error {
+		state, err := client.GetCameraState(ctx)
+		if err != nil {
+			return err
+		}
+		return changeSettings(ctx, logger, client, state, changes, isDryRun(cmd))
+	})
+}
+
+// runSettingsDiff is the entry point for the "settings diff" subcommand.
+func runSettingsDiff(cmd *cobra.Command, args []string) error {
+	ctx, _, cfg, err := contextLoggerConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	desired, err := desiredSettings(cfg)
+	if err != nil {
+		return err
+	}
+
+	return forEachCamera(cmd, cfg, func(logger *slog.Logger, cam config.Camera, client *gopro.Client) error {
+		state, err := client.GetCameraState(ctx)
+		if err != nil {
+			return err
+		}
+
+		if cam.Name != "" {
+			fmt.Printf("== %s ==\n", cam.Name)
+		}
+
+		changes := settingsDiff(state, desired)
+		if len(changes) == 0 {
+			fmt.Println("Settings match the profile.")
+			return nil
+		}
+		for _, d := range changes {
+			fmt.Printf("%-16s %s -> %s\n", d.Key+":", currentOption(state, d.Setting), d.OptionName(d.Option))
+		}
+
+		return nil
+	})
+}
+
+// runSettingsApply is the entry point for the "settings apply" subcommand.
+func runSettingsApply(cmd *cobra.Command, args []string) error {
+	ctx, _, cfg, err := contextLoggerConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	desired, err := desiredSettings(cfg)
+	if err != nil {
+		return err
+	}
+
+	return forEachCamera(cmd, cfg, func(logger *slog.Logger, cam config.Camera, client *gopro.Client) error {
+		state, err := client.GetCameraState(ctx)
+		if err != nil {
+			return err
+		}
+
+		changes := settingsDiff(state, desired)
+		if len(changes) == 0 {
+			logger.Info("camera settings already match the profile")
+			return nil
+		}
+		return changeSettings(ctx, logger, client, state, changes, isDryRun(cmd))
+	})
+}
+
+// desiredSettings returns the configured settings profile, which must not be
+// empty.
+func desiredSettings(cfg *config.Config) ([]gopro.SettingValue, error) {
+	desired, err := gopro.ParseSettingsProfile(cfg.Settings)
+	if err != nil {
+		return nil, fmt.Errorf("invalid settings profile: %w", err)
+	}
+	if len(desired) == 0 {
+		return nil, fmt.Errorf("no settings profile configured; add a [settings] section to the config file")
+	}
+	return desired, nil
+}
+
+// settingsDiff returns the desired settings that differ from the camera's.
+func settingsDiff(state *gopro.CameraState, desired []gopro.SettingValue) []gopro.SettingValue {
+	var changes []gopro.SettingValue
+	for _, d := range desired {
+		if value, ok := state.Setting(d.ID); !ok || value != d.Option {
+			changes = append(changes, d)
+		}
+	}
+	return changes
+}
+
+// changeSettings applies the changes in order, as some options are only
+// available after others are set (e.g., frame rates depend on resolution).
+func changeSettings(ctx context.Context, logger *slog.Logger, client *gopro.Client, state *gopro.CameraState, changes []gopro.SettingValue, dryRun bool) error {
+	if dryRun {
+		for _, c := range changes {
+			printPlan("change %s from %s to %s", c.Key, currentOption(state, c.Setting), c.OptionName(c.Option))
+		}
+		return nil
+	}
+
+	if err := checkIdle(state); err != nil {
+		return err
+	}
+
+	for _, c := range changes {
+		if err := client.SetSetting(ctx, c.ID, c.Option); err != nil {
+			return fmt.Errorf("setting %s to %s: %w", c.Key, c.OptionName(c.Option), err)
+		}
+		logger.Info("setting changed", slog.String("setting", c.Key), slog.String("option", c.OptionName(c.Option)))
+	}
+
+	return nil
+}
+
+// currentOption returns the name of the camera's current option for the setting.
+func currentOption(state *gopro.CameraState, setting gopro.Setting) string {
+	value, ok := state.Setting(setting.ID)
+	if !ok {
+		return "unknown"
+	}
+	return setting.OptionName(value)
+}
@@ -1,12 +1,18 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"time"
 
 	"github.com/dustin/go-humanize"
 	"github.com/spf13/cobra"
 
+	"github.com/EarthmanMuons/herosync/config"
 	"github.com/EarthmanMuons/herosync/internal/gopro"
+	"github.com/EarthmanMuons/herosync/internal/ytquota"
 )
 
 // newStatusCmd constructs the "status" subcommand.
@@ -21,16 +27,26 @@ func newStatusCmd() *cobra.Command {
 
 // runStatus is the entry point for the "status" subcommand.
 func runStatus(cmd *cobra.Command, args []string) error {
-	ctx, logger, cfg, err := contextLoggerConfig(cmd)
+	ctx, _, cfg, err := contextLoggerConfig(cmd)
 	if err != nil {
 		return err
 	}
 
-	client, err := gopro.NewClient(logger, cfg.GoPro.Scheme, cfg.GoPro.Host)
+	cameraErr := forEachCamera(cmd, cfg, func(logger *slog.Logger, cam config.Camera, client *gopro.Client) error {
+		return printCameraStatus(ctx, logger, cfg, cam, client)
+	})
+
+	ledger, err := ytquota.Load(defaultQuotaLedgerPath(), cfg.YouTube.DailyQuota)
 	if err != nil {
-		return err
+		return errors.Join(cameraErr, err)
 	}
+	fmt.Printf("YouTube Quota: %s\n", formatQuotaStatus(ledger))
+
+	return cameraErr
+}
 
+// printCameraStatus prints the hardware, storage, and clock details of a camera.
+func printCameraStatus(ctx context.Context, logger *slog.Logger, cfg *config.Config, cam config.Camera, client *gopro.Client) error {
 	hw, err := client.GetHardwareInfo(ctx)
 	if err != nil {
 		return err
@@ -43,10 +59,25 @@ func runStatus(cmd *cobra.Command, args []string) error {
 
 	storageStatus := formatStorageStatus(cs.Status.SDCardCapacity, cs.Status.SDCardRemaining)
 
+	if cam.Name != "" {
+		fmt.Printf("Camera: %s\n", cam.Name)
+	}
 	fmt.Printf("Connected to GoPro %s at %s\n", hw.ModelName, client.BaseURL())
 	fmt.Printf("Serial Number: %s\n", hw.SerialNumber)
 	fmt.Printf("Firmware Version: %s\n", hw.FirmwareVersion)
 	fmt.Printf("Storage: %s\n", storageStatus)
+	fmt.Printf("Battery: %s\n", formatBatteryStatus(cs.Status.BatteryPercent, cs.Status.Charging(), cs.Status.USBConnected))
+	fmt.Printf("Activity: %s\n", formatActivityStatus(cs.Status.Busy, cs.Status.Encoding))
+	fmt.Printf("Temperature: %s\n", formatThermalStatus(cs.Status.SystemHot, cs.Status.TooCold))
+
+	drift, err := client.ClockDrift(ctx)
+	if err != nil {
+		return err
+	}
+	if clockDrifted(drift, cfg.Clock.MaxDrift) {
+		logger.Warn("camera clock has drifted", slog.Duration("drift", drift), slog.Duration("max-drift", cfg.Clock.MaxDrift))
+	}
+	fmt.Printf("Clock: %s\n\n", formatClockDrift(drift, cfg.Clock.MaxDrift))
 
 	return nil
 }
@@ -62,3 +93,50 @@ func formatStorageStatus(capacityBytes, remainingBytes int64) string {
 
 	return fmt.Sprintf("%.1f%% full (%s free)", percentageFull, humanRemaining)
 }
+
+func formatBatteryStatus(percent int, charging, usb bool) string {
+	switch {
+	case charging:
+		return fmt.Sprintf("%d%% (charging)", percent)
+	case usb:
+		return fmt.Sprintf("%d%% (USB connected)", percent)
+	default:
+		return fmt.Sprintf("%d%%", percent)
+	}
+}
+
+func formatActivityStatus(busy, encoding bool) string {
+	switch {
+	case encoding:
+		return "encoding"
+	case busy:
+		return "busy"
+	default:
+		return "ready"
+	}
+}
+
+func formatThermalStatus(hot, cold bool) string {
+	switch {
+	case hot:
+		return "too hot"
+	case cold:
+		return "too cold to record"
+	default:
+		return "normal"
+	}
+}
+
+func formatQuotaStatus(ledger *ytquota.Ledger) string {
+	resetsAt := ledger.ResetsAt().Local().Format(time.DateTime)
+
+	if ledger.Exhausted {
+		return fmt.Sprintf("exhausted (resets %s)", resetsAt)
+	}
+
+	remaining := ledger.Remaining()
+	uploads := remaining / ytquota.CostVideosInsert
+
+	return fmt.Sprintf("%s of %s units remaining, about %d upload(s) (resets %s)",
+		humanize.Comma(int64(remaining)), humanize.Comma(int64(ledger.Limit())), uploads, resetsAt)
+}
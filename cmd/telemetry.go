@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/EarthmanMuons/herosync/internal/fsutil"
+	"github.com/EarthmanMuons/herosync/internal/gpmf"
+	"github.com/EarthmanMuons/herosync/internal/media"
+)
+
+// newTelemetryCmd constructs the "telemetry" subcommand.
+func newTelemetryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "telemetry [FILENAME]...",
+		Aliases: []string{"gpmf"},
+		Short:   "Export GPMF telemetry from local videos",
+		Long: `Export GPMF telemetry from local videos.
+
+Reads the GPS, accelerometer, gyroscope, and temperature samples recorded by
+the GoPro and writes them as sidecar files next to each video in the "incoming"
+and "outgoing" media subdirectories. Timestamps are aligned to the video's
+creation time.
+
+Supported formats:
+
+* gpx      GPS track (.gpx)
+* geojson  GPS track as a LineString (.geojson)
+* csv      one file per sensor (.gps.csv, .accl.csv, .gyro.csv, .tmpc.csv)
+
+If one or more [FILENAME] arguments are provided, only matching files will be
+affected.`,
+		Args: cobra.ArbitraryArgs,
+		RunE: runTelemetry,
+	}
+
+	cmd.Flags().StringSlice("format", nil, "export formats (gpx, geojson, csv) [default: from config]")
+
+	return cmd
+}
+
+// runTelemetry is the entry point for the "telemetry" subcommand.
+func runTelemetry(cmd *cobra.Command, args []string) error {
+	ctx, logger, cfg, err := contextLoggerConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	formats := cfg.TelemetryFormats()
+	if cmd.Flags().Changed("format") {
+		formats, _ = cmd.Flags().GetStringSlice("format")
+	}
+
+	dirs, err := incomingDirs(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	inventory, err := media.NewLocalInventory(ctx, dirs, cfg.OutgoingMediaDir())
+	if err != nil {
+		return err
+	}
+
+	if len(args) > 0 {
+		inventory, err = inventory.FilterByDisplayInfo(args)
+		if err != nil {
+			return err
+		}
+	}
+
+	dryRun := isDryRun(cmd)
+
+	for _, file := range inventory.Files {
+		path := filepath.Join(file.Directory, file.Filename)
+
+		if dryRun {
+			printPlan("export telemetry from %s as %s", fsutil.ShortenPath(path), strings.Join(formats, ", "))
+			continue
+		}
+
+		t, err := gpmf.Extract(path, file.CreatedAt)
+		if err != nil {
+			if errors.Is(err, gpmf.ErrNoTelemetry) {
+				logger.Warn("no telemetry found", slog.String("filename", file.Filename))
+				continue
+			}
+			logger.Error("failed to extract telemetry", slog.String("filename", file.Filename), slog.Any("error", err))
+			continue
+		}
+
+		written, err := writeTelemetrySidecars(path, t, formats)
+		if err != nil {
+			logger.Error("failed to export telemetry", slog.String("filename", file.Filename), slog.Any("error", err))
+			continue
+		}
+
+		for _, sidecar := range written {
+			logger.Info("telemetry exported", slog.String("path", fsutil.ShortenPath(sidecar)))
+		}
+	}
+
+	return nil
+}
+
+// extractTelemetry reads and concatenates the telemetry from each file of a
+// group, aligning every file's samples to its own creation time.
+func extractTelemetry(inv *media.Inventory, dir string) (*gpmf.Telemetry, error) {
+	combined := &gpmf.Telemetry{}
+	for _, file := range inv.Files {
+		t, err := gpmf.Extract(filepath.Join(dir, file.Filename), file.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("extracting telemetry from %s: %w", file.Filename, err)
+		}
+		combined.GPS = append(combined.GPS, t.GPS...)
+		combined.Accelerometer = append(combined.Accelerometer, t.Accelerometer...)
+		combined.Gyroscope = append(combined.Gyroscope, t.Gyroscope...)
+		combined.Temperature = append(combined.Temperature, t.Temperature...)
+	}
+	return combined, nil
+}
+
+// telemetrySidecarSuffixes are appended to a video's base name to form the
+// names of its telemetry sidecars, across every export format.
+var telemetrySidecarSuffixes = []string{".gpx", ".geojson", ".gps.csv", ".accl.csv", ".gyro.csv", ".tmpc.csv"}
+
+// writeTelemetrySidecars writes telemetry files next to the video at
+// videoPath in each of the requested formats, returning the paths written.
+func writeTelemetrySidecars(videoPath string, t *gpmf.Telemetry, formats []string) ([]string, error) {
+	base := strings.TrimSuffix(videoPath, filepath.Ext(videoPath))
+	name := filepath.Base(base)
+
+	type sidecar struct {
+		path  string
+		write func(io.Writer) error
+		empty bool
+	}
+
+	var sidecars []sidecar
+	for _, format := range formats {
+		switch strings.ToLower(format) {
+		case "gpx":
+			sidecars = append(sidecars, sidecar{base + ".gpx", func(w io.Writer) error { return gpmf.WriteGPX(w, t, name) }, len(t.GPS) == 0})
+		case "geojson":
+			sidecars = append(sidecars, sidecar{base + ".geojson", func(w io.Writer) error { return gpmf.WriteGeoJSON(w, t, name) }, len(t.GPS) == 0})
+		case "csv":
+			sidecars = append(sidecars,
+				sidecar{base + ".gps.csv", func(w io.Writer) error { return gpmf.WriteGPSCSV(w, t) }, len(t.GPS) == 0},
+				sidecar{base + ".accl.csv", func(w io.Writer) error { return gpmf.WriteVectorCSV(w, t.Accelerometer) }, len(t.Accelerometer) == 0},
+				sidecar{base + ".gyro.csv", func(w io.Writer) error { return gpmf.WriteVectorCSV(w, t.Gyroscope) }, len(t.Gyroscope) == 0},
+				sidecar{base + ".tmpc.csv", func(w io.Writer) error { return gpmf.WriteTemperatureCSV(w, t.Temperature) }, len(t.Temperature) == 0},
+			)
+		default:
+			return nil, fmt.Errorf("invalid telemetry format: %q", format)
+		}
+	}
+
+	var written []string
+	for _, s := range sidecars {
+		if s.empty {
+			continue
+		}
+		if err := writeFile(s.path, s.write); err != nil {
+			return written, err
+		}
+		written = append(written, s.path)
+	}
+
+	return written, nil
+}
+
+// writeFile creates the file at path and fills it using write.
+func writeFile(path string, write func(io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating file: %w", err)
+	}
+
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("closing file: %w", err)
+	}
+
+	return nil
+}
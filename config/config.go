@@ -5,9 +5,12 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/adrg/xdg"
+	"github.com/dustin/go-humanize"
 	"github.com/knadh/koanf/parsers/toml/v2"
 	"github.com/knadh/koanf/providers/confmap"
 	"github.com/knadh/koanf/providers/env"
@@ -19,19 +22,87 @@ import (
 var k = koanf.New(".")
 
 type Config struct {
+	CameraProfiles map[string]struct {
+		Host            string `koanf:"host"`
+		Scheme          string `koanf:"scheme"`
+		Serial          string `koanf:"serial"`
+		Username        string `koanf:"username"`
+		Password        string `koanf:"password"`
+		CACert          string `koanf:"ca-cert"`
+		CertFingerprint string `koanf:"cert-fingerprint"`
+	} `koanf:"cameras"`
+	Clock struct {
+		MaxDrift       time.Duration `koanf:"max-drift"`
+		SyncOnDownload bool          `koanf:"sync-on-download"`
+	} `koanf:"clock"`
+	Combine struct {
+		Overlay        bool `koanf:"overlay"`
+		PreserveTracks bool `koanf:"preserve-tracks"`
+	} `koanf:"combine"`
 	GoPro struct {
-		Host   string `koanf:"host"`
-		Scheme string `koanf:"scheme"`
+		Host            string `koanf:"host"`
+		Scheme          string `koanf:"scheme"`
+		Username        string `koanf:"username"`
+		Password        string `koanf:"password"`
+		CACert          string `koanf:"ca-cert"`
+		CertFingerprint string `koanf:"cert-fingerprint"`
 	} `koanf:"gopro"`
 	Group struct {
 		By string `koanf:"by"`
 	} `koanf:"group"`
+	HTTP struct {
+		Query    RequestPolicy `koanf:"query"`
+		Download RequestPolicy `koanf:"download"`
+		Delete   RequestPolicy `koanf:"delete"`
+		Control  RequestPolicy `koanf:"control"`
+	} `koanf:"http"`
+	Location struct {
+		Fallback    string  `koanf:"fallback"`
+		Gazetteer   string  `koanf:"gazetteer"`
+		MaxDistance float64 `koanf:"max-distance"`
+	} `koanf:"location"`
 	Log struct {
 		Level string `koanf:"level"`
 	} `koanf:"log"`
 	Media struct {
-		Dir string `koanf:"dir"`
+		Dir     string   `koanf:"dir"`
+		MinFree ByteSize `koanf:"min-free"`
 	} `koanf:"media"`
+	Overlay struct {
+		Gauges      string `koanf:"gauges"`
+		Units       string `koanf:"units"`
+		EncoderArgs string `koanf:"encoder-args"`
+	} `koanf:"overlay"`
+	Retime []struct {
+		MediaID int           `koanf:"media-id"`
+		Date    string        `koanf:"date"`
+		Offset  time.Duration `koanf:"offset"`
+	} `koanf:"retime"`
+	Retention struct {
+		IncomingMaxSize ByteSize `koanf:"incoming-max-size"`
+		IncomingMaxAge  int      `koanf:"incoming-max-age"`
+		OutgoingMaxSize ByteSize `koanf:"outgoing-max-size"`
+		OutgoingMaxAge  int      `koanf:"outgoing-max-age"`
+	} `koanf:"retention"`
+	Settings  map[string]string `koanf:"settings"`
+	Telemetry struct {
+		Formats   string `koanf:"formats"`
+		OnCombine bool   `koanf:"on-combine"`
+	} `koanf:"telemetry"`
+	Transfer struct {
+		ReadyTimeout time.Duration `koanf:"ready-timeout"`
+		MinBattery   int           `koanf:"min-battery"`
+		KeepAlive    time.Duration `koanf:"keep-alive"`
+		LimitRate    string        `koanf:"limit-rate"`
+		Schedule     []struct {
+			Start     string `koanf:"start"`
+			End       string `koanf:"end"`
+			LimitRate string `koanf:"limit-rate"`
+		} `koanf:"schedule"`
+	} `koanf:"transfer"`
+	Trash struct {
+		MaxAge int `koanf:"max-age"`
+	} `koanf:"trash"`
 	Video struct {
 		Title         string `koanf:"title"`
 		Description   string `koanf:"description"`
@@ -39,6 +110,31 @@ type Config struct {
 		CategoryID    string `koanf:"category-id"`
 		PrivacyStatus string `koanf:"privacy-status"`
 	} `koanf:"video"`
+	YouTube struct {
+		DailyQuota  int    `koanf:"daily-quota"`
+		PostPublish string `koanf:"post-publish"`
+		ArchiveDir  string `koanf:"archive-dir"`
+		DeleteAfter int    `koanf:"delete-after"`
+	} `koanf:"youtube"`
+}
+
+// ByteSize is a size in bytes that can be configured in human-readable form
+// (e.g., "500MB" or "2 GiB").
+type ByteSize uint64
+
+// UnmarshalText parses a human-readable byte size.
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	n, err := humanize.ParseBytes(string(text))
+	if err != nil {
+		return fmt.Errorf("invalid byte size: %q", text)
+	}
+	*b = ByteSize(n)
+	return nil
+}
+
+// String returns the byte size in human-readable form.
+func (b ByteSize) String() string {
+	return humanize.Bytes(uint64(b))
 }
 
 // DefaultConfigPath returns the default config file path following XDG specification.
@@ -51,11 +147,15 @@ func DefaultMediaDir() string {
 	return filepath.Join(xdg.DataHome, "herosync", "media")
 }
 
-func Init(configFile string, flags map[string]any) error {
-	// 1. Load default values (lowest priority)
+func Init(configFile string, defaults, flags map[string]any) error {
+	// 1. Load default values (lowest priority), including those owned by
+	// other packages, such as the GoPro client's request policies
 	if err := loadDefaults(); err != nil {
 		return err
 	}
+	if err := k.Load(confmap.Provider(defaults, "."), nil); err != nil {
+		return err
+	}
 
 	// 2. Load configuration file
 	if err := loadFile(configFile); err != nil {
@@ -77,16 +177,46 @@ func Init(configFile string, flags map[string]any) error {
 
 func loadDefaults() error {
 	defaults := map[string]any{
-		"gopro.host":           "", // Empty means use mDNS discovery
-		"gopro.scheme":         "http",
-		"group.by":             "chapters",
-		"log.level":            "info",
-		"media.dir":            DefaultMediaDir(),
-		"video.title":          "GoPro ${identifier} ${counter}",
-		"video.description":    "Uploaded via herosync.",
-		"video.tags":           "",
-		"video.category-id":    "22",
-		"video.privacy-status": "private",
+		"clock.max-drift":             "10s",
+		"clock.sync-on-download":      false,
+		"combine.overlay":             false,
+		"combine.preserve-tracks":     false,
+		"gopro.host":                  "", // Empty means use mDNS discovery
+		"gopro.scheme":                "http",
+		"gopro.username":              "",
+		"gopro.password":              "",
+		"gopro.ca-cert":               "",
+		"gopro.cert-fingerprint":      "", // SHA-256, pins the COHN certificate
+		"group.by":                    "chapters",
+		"location.fallback":           "", // "latitude,longitude"; empty means none
+		"location.gazetteer":          "", // CSV of name,latitude,longitude; empty disables place names
+		"location.max-distance":       25, // kilometers
+		"log.level":                   "info",
+		"media.dir":                   DefaultMediaDir(),
+		"media.min-free":              "2GB",
+		"overlay.gauges":              "speed,altitude,map",
+		"overlay.units":               "metric",
+		"overlay.encoder-args":        "-c:v libx264 -preset medium -crf 20",
+		"retention.incoming-max-size": "0", // Zero disables the limit
+		"retention.incoming-max-age":  0,   // days
+		"retention.outgoing-max-size": "0",
+		"retention.outgoing-max-age":  0,
+		"telemetry.formats":           "gpx,geojson,csv",
+		"telemetry.on-combine":        false,
+		"transfer.keep-alive":         "3s", // zero disables
+		"transfer.limit-rate":         "unlimited",
+		"transfer.ready-timeout":      "2m",
+		"transfer.min-battery":        20, // percent; ignored on external power
+		"trash.max-age":               30, // days; zero disables purging
+		"video.title":                 "GoPro ${identifier} ${counter}",
+		"video.description":           "Uploaded via herosync.",
+		"video.tags":                  "",
+		"video.category-id":           "22",
+		"video.privacy-status":        "private",
+		"youtube.daily-quota":         10000,
+		"youtube.post-publish":        "keep",
+		"youtube.archive-dir":         "", // Empty means the "archive" media subdirectory
+		"youtube.delete-after":        0,
 	}
 	return k.Load(confmap.Provider(defaults, "."), nil)
 }
@@ -130,6 +260,18 @@ func validateConfig(cfg *Config) error {
 		return fmt.Errorf("invalid scheme: %q (choose http or https)", cfg.GoPro.Scheme)
 	}
 
+	for name, cam := range cfg.CameraProfiles {
+		switch cam.Scheme {
+		case "", "http", "https":
+			// valid
+		default:
+			return fmt.Errorf("invalid scheme for camera %q: %q (choose http or https)", name, cam.Scheme)
+		}
+		if len(cfg.CameraProfiles) > 1 && cam.Host == "" && cam.Serial == "" {
+			return fmt.Errorf("camera %q needs a host or serial to tell it apart from other cameras", name)
+		}
+	}
+
 	switch cfg.Group.By {
 	case "chapters", "date":
 		// valid
@@ -137,6 +279,107 @@ func validateConfig(cfg *Config) error {
 		return fmt.Errorf("invalid grouping: %q (choose chapters or date)", cfg.Group.By)
 	}
 
+	if cfg.Clock.MaxDrift <= 0 {
+		return fmt.Errorf("invalid clock max drift: %s (must be positive)", cfg.Clock.MaxDrift)
+	}
+
+	if cfg.Transfer.ReadyTimeout < 0 {
+		return fmt.Errorf("invalid transfer ready timeout: %s (must not be negative)", cfg.Transfer.ReadyTimeout)
+	}
+	for name, p := range map[string]RequestPolicy{"query": cfg.HTTP.Query, "download": cfg.HTTP.Download, "delete": cfg.HTTP.Delete, "control": cfg.HTTP.Control} {
+		if p.Timeout < 0 || p.IdleTimeout < 0 || p.BackoffMin < 0 || p.BackoffMax < 0 {
+			return fmt.Errorf("invalid http %s policy: durations must not be negative", name)
+		}
+		if p.Retries < 0 {
+			return fmt.Errorf("invalid http %s retries: %d (must not be negative)", name, p.Retries)
+		}
+	}
+	// Deletes and control commands aren't safe to replay, so they only take a
+	// timeout, and only downloads stream a body for the idle timeout to watch.
+	for name, p := range map[string]RequestPolicy{"delete": cfg.HTTP.Delete, "control": cfg.HTTP.Control} {
+		if p.Retries != 0 || p.BackoffMin != 0 || p.BackoffMax != 0 || p.IdleTimeout != 0 {
+			return fmt.Errorf("invalid http %s policy: only timeout can be set, as these requests are never retried", name)
+		}
+	}
+	if cfg.HTTP.Query.IdleTimeout != 0 {
+		return fmt.Errorf("invalid http query policy: idle-timeout only applies to downloads")
+	}
+
+	if cfg.Transfer.KeepAlive < 0 {
+		return fmt.Errorf("invalid transfer keep-alive interval: %s (must not be negative)", cfg.Transfer.KeepAlive)
+	}
+	// The ready timeout also bounds the wait for a camera to come back after it
+	// stops answering keep-alives mid-transfer.
+	if cfg.Transfer.KeepAlive > 0 && cfg.Transfer.ReadyTimeout == 0 {
+		return fmt.Errorf("invalid transfer ready timeout: %s (must be positive while keep-alive is enabled)", cfg.Transfer.ReadyTimeout)
+	}
+	if cfg.Transfer.MinBattery < 0 || cfg.Transfer.MinBattery > 100 {
+		return fmt.Errorf("invalid transfer min battery: %d (must be 0-100)", cfg.Transfer.MinBattery)
+	}
+
+	if cfg.YouTube.DailyQuota <= 0 {
+		return fmt.Errorf("invalid daily quota: %d (must be positive)", cfg.YouTube.DailyQuota)
+	}
+
+	switch cfg.YouTube.PostPublish {
+	case "keep", "archive", "delete":
+		// valid
+	default:
+		return fmt.Errorf("invalid post-publish action: %q (choose keep, archive, or delete)", cfg.YouTube.PostPublish)
+	}
+
+	if cfg.YouTube.DeleteAfter < 0 {
+		return fmt.Errorf("invalid delete-after days: %d (must not be negative)", cfg.YouTube.DeleteAfter)
+	}
+
+	for _, format := range cfg.TelemetryFormats() {
+		switch format {
+		case "gpx", "geojson", "csv":
+			// valid
+		default:
+			return fmt.Errorf("invalid telemetry format: %q (choose gpx, geojson, or csv)", format)
+		}
+	}
+
+	switch cfg.Overlay.Units {
+	case "metric", "imperial":
+		// valid
+	default:
+		return fmt.Errorf("invalid overlay units: %q (choose metric or imperial)", cfg.Overlay.Units)
+	}
+
+	// The overlay filter can't be applied while stream copying, so the video
+	// must be re-encoded with an explicit encoder.
+	if strings.TrimSpace(cfg.Overlay.EncoderArgs) == "" {
+		return fmt.Errorf("overlay encoder args must not be empty (e.g. %q)", "-c:v libx264 -preset medium -crf 20")
+	}
+
+	if cfg.Location.MaxDistance < 0 {
+		return fmt.Errorf("invalid location max distance: %g (must not be negative)", cfg.Location.MaxDistance)
+	}
+
+	for _, r := range cfg.Retime {
+		if (r.MediaID == 0) == (r.Date == "") {
+			return fmt.Errorf("invalid retime entry: set exactly one of media-id or date")
+		}
+		if r.Date != "" {
+			if _, err := time.Parse(time.DateOnly, r.Date); err != nil {
+				return fmt.Errorf("invalid retime date: %q (use YYYY-MM-DD)", r.Date)
+			}
+		}
+		if r.Offset == 0 {
+			return fmt.Errorf("invalid retime offset for %s: must not be zero", retimeTarget(r.MediaID, r.Date))
+		}
+	}
+
+	if cfg.Trash.MaxAge < 0 {
+		return fmt.Errorf("invalid trash max age: %d (must not be negative)", cfg.Trash.MaxAge)
+	}
+
+	if cfg.Retention.IncomingMaxAge < 0 || cfg.Retention.OutgoingMaxAge < 0 {
+		return fmt.Errorf("invalid retention max age: must not be negative")
+	}
+
 	// Try unmarshalling the log level to validate it.
 	var level slog.Level
 	if err := level.UnmarshalText([]byte(cfg.Log.Level)); err != nil {
@@ -151,7 +394,137 @@ func (c *Config) IncomingMediaDir() string {
 	return filepath.Join(c.Media.Dir, "incoming")
 }
 
+// Camera is a resolved camera profile.
+type Camera struct {
+	Name        string // empty for the single camera configured in [gopro]
+	Host        string // empty means use mDNS discovery
+	Scheme      string
+	Serial      string // used to pick the camera among discovered ones
+	Username    string
+	Password    string
+	CACert      string // path to the camera's COHN root CA certificate
+	Fingerprint string // SHA-256 fingerprint pinning the camera's certificate
+	IncomingDir string
+}
+
+// String returns the camera's name for display.
+func (c Camera) String() string {
+	if c.Name == "" {
+		return "default"
+	}
+	return c.Name
+}
+
+// Cameras returns the configured camera profiles sorted by name. Without any
+// [cameras] entries, the single camera from the [gopro] section is returned,
+// which keeps using the incoming directory itself rather than a subdirectory.
+func (c *Config) Cameras() []Camera {
+	if len(c.CameraProfiles) == 0 {
+		return []Camera{{
+			Host:        c.GoPro.Host,
+			Scheme:      c.GoPro.Scheme,
+			Username:    c.GoPro.Username,
+			Password:    c.GoPro.Password,
+			CACert:      c.GoPro.CACert,
+			Fingerprint: c.GoPro.CertFingerprint,
+			IncomingDir: c.IncomingMediaDir(),
+		}}
+	}
+
+	cameras := make([]Camera, 0, len(c.CameraProfiles))
+	for name, profile := range c.CameraProfiles {
+		scheme := profile.Scheme
+		if scheme == "" {
+			scheme = c.GoPro.Scheme
+		}
+		cameras = append(cameras, Camera{
+			Name:        name,
+			Host:        profile.Host,
+			Scheme:      scheme,
+			Serial:      profile.Serial,
+			Username:    profile.Username,
+			Password:    profile.Password,
+			CACert:      profile.CACert,
+			Fingerprint: profile.CertFingerprint,
+			IncomingDir: filepath.Join(c.IncomingMediaDir(), name),
+		})
+	}
+
+	slices.SortFunc(cameras, func(a, b Camera) int { return strings.Compare(a.Name, b.Name) })
+	return cameras
+}
+
+// SelectCameras returns the named camera profiles, or all of them if no names
+// are given.
+func (c *Config) SelectCameras(names []string) ([]Camera, error) {
+	cameras := c.Cameras()
+	if len(names) == 0 {
+		return cameras, nil
+	}
+
+	var selected []Camera
+	for _, name := range names {
+		i := slices.IndexFunc(cameras, func(cam Camera) bool { return cam.Name == name })
+		if i < 0 {
+			return nil, fmt.Errorf("unknown camera: %q", name)
+		}
+		selected = append(selected, cameras[i])
+	}
+	return selected, nil
+}
+
 // OutgoingMediaDir returns the full path to the outgoing media directory.
 func (c *Config) OutgoingMediaDir() string {
 	return filepath.Join(c.Media.Dir, "outgoing")
 }
+
+// RequestPolicy holds the timeouts and retries for one kind of GoPro request.
+type RequestPolicy struct {
+	Timeout     time.Duration `koanf:"timeout"`
+	IdleTimeout time.Duration `koanf:"idle-timeout"`
+	Retries     int           `koanf:"retries"`
+	BackoffMin  time.Duration `koanf:"backoff-min"`
+	BackoffMax  time.Duration `koanf:"backoff-max"`
+}
+
+// retimeTarget describes what a retime entry applies to.
+func retimeTarget(mediaID int, date string) string {
+	if mediaID != 0 {
+		return fmt.Sprintf("media ID %d", mediaID)
+	}
+	return date
+}
+
+// TelemetryFormats returns the list of configured telemetry export formats.
+func (c *Config) TelemetryFormats() []string {
+	return splitList(c.Telemetry.Formats)
+}
+
+// OverlayGauges returns the list of configured overlay gauges, in display order.
+func (c *Config) OverlayGauges() []string {
+	return splitList(c.Overlay.Gauges)
+}
+
+// splitList splits a comma-separated config value into lowercase items.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// TrashDir returns the full path to the trash directory for deleted media.
+func (c *Config) TrashDir() string {
+	return filepath.Join(c.Media.Dir, "trash")
+}
+
+// ArchiveMediaDir returns the full path to the directory for published videos.
+func (c *Config) ArchiveMediaDir() string {
+	if c.YouTube.ArchiveDir != "" {
+		return c.YouTube.ArchiveDir
+	}
+	return filepath.Join(c.Media.Dir, "archive")
+}
@@ -0,0 +1,18 @@
+//go:build !(darwin || linux)
+
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+)
+
+// FreeSpace is not supported on this platform.
+func FreeSpace(path string) (uint64, error) {
+	return 0, fmt.Errorf("free space of %s: %w", path, errors.ErrUnsupported)
+}
+
+// SameFilesystem is not supported on this platform.
+func SameFilesystem(a, b string) (bool, error) {
+	return false, fmt.Errorf("filesystems of %s and %s: %w", a, b, errors.ErrUnsupported)
+}
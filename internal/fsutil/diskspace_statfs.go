@@ -0,0 +1,31 @@
+//go:build darwin || linux
+
+package fsutil
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// FreeSpace returns the number of bytes available to unprivileged users on
+// the filesystem containing path, or its nearest existing parent directory.
+func FreeSpace(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(existingParent(path), &stat); err != nil {
+		return 0, fmt.Errorf("statfs %s: %w", path, err)
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
+
+// SameFilesystem reports whether a and b, or their nearest existing parent
+// directories, are on the same filesystem.
+func SameFilesystem(a, b string) (bool, error) {
+	var statA, statB syscall.Stat_t
+	if err := syscall.Stat(existingParent(a), &statA); err != nil {
+		return false, fmt.Errorf("stat %s: %w", a, err)
+	}
+	if err := syscall.Stat(existingParent(b), &statB); err != nil {
+		return false, fmt.Errorf("stat %s: %w", b, err)
+	}
+	return statA.Dev == statB.Dev, nil
+}
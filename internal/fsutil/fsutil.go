@@ -1,14 +1,38 @@
 package fsutil
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/dustin/go-humanize"
 )
 
+// ErrInsufficientSpace is returned when a filesystem lacks room for pending writes.
+var ErrInsufficientSpace = errors.New("insufficient disk space")
+
+// EnsureFreeSpace verifies that the filesystem containing dir can hold the
+// required number of bytes while still leaving the given headroom free.
+func EnsureFreeSpace(dir string, required, headroom uint64) error {
+	available, err := FreeSpace(dir)
+	if err != nil {
+		return err
+	}
+
+	if available < required+headroom {
+		return fmt.Errorf("%w in %s: need %s plus %s headroom, only %s available",
+			ErrInsufficientSpace, ShortenPath(dir),
+			humanize.Bytes(required), humanize.Bytes(headroom), humanize.Bytes(available))
+	}
+
+	return nil
+}
+
 // GenerateUniqueFilename generates a unique filename based on the provided base path.
 // If the file already exists, it appends a counter (e.g., "_1", "_2") before the extension.
 func GenerateUniqueFilename(basePath string) (string, error) {
@@ -33,6 +57,50 @@ func GenerateUniqueFilename(basePath string) (string, error) {
 	}
 }
 
+// MoveFile moves a file to a new path, falling back to copying and removing
+// the original when the destination is on a different filesystem.
+func MoveFile(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o750); err != nil {
+		return fmt.Errorf("creating directory: %w", err)
+	}
+
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	info, err := os.Stat(src)
+	if err != nil {
+		return fmt.Errorf("stat file %s: %w", src, err)
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", src, err)
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", dst, err)
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return fmt.Errorf("copying %s: %w", src, err)
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return fmt.Errorf("closing %s: %w", dst, err)
+	}
+
+	if err := os.Chtimes(dst, time.Now(), info.ModTime()); err != nil {
+		return fmt.Errorf("set mtime on %s: %w", dst, err)
+	}
+
+	return os.Remove(src)
+}
+
 // SetMtime sets the modification time (mtime) of the file at the given path.
 func SetMtime(logger *slog.Logger, path string, mtime time.Time) error {
 	if err := os.Chtimes(path, time.Now(), mtime); err != nil {
@@ -84,3 +152,17 @@ func VerifySize(path string, expectedSize int64, tolerance float64) error {
 func VerifySizeExact(path string, expectedSize int64) error {
 	return VerifySize(path, expectedSize, 0.0)
 }
+
+// existingParent returns path or the nearest parent directory that exists.
+func existingParent(path string) string {
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
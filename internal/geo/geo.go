@@ -0,0 +1,138 @@
+// Package geo provides geographic coordinates and offline reverse geocoding
+// against a local gazetteer file.
+package geo
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const earthRadiusKm = 6371.0
+
+// Point is a position in decimal degrees, with an optional altitude in meters.
+type Point struct {
+	Latitude  float64
+	Longitude float64
+	Altitude  float64
+}
+
+// ParsePoint parses a "latitude,longitude" string.
+func ParsePoint(s string) (Point, error) {
+	lat, lon, ok := strings.Cut(s, ",")
+	if !ok {
+		return Point{}, fmt.Errorf("invalid location %q: expected \"latitude,longitude\"", s)
+	}
+
+	p, err := newPoint(lat, lon)
+	if err != nil {
+		return Point{}, fmt.Errorf("invalid location %q: %w", s, err)
+	}
+	return p, nil
+}
+
+// newPoint parses and validates latitude and longitude strings.
+func newPoint(lat, lon string) (Point, error) {
+	latitude, err := strconv.ParseFloat(strings.TrimSpace(lat), 64)
+	if err != nil || latitude < -90 || latitude > 90 {
+		return Point{}, fmt.Errorf("latitude out of range: %q", lat)
+	}
+
+	longitude, err := strconv.ParseFloat(strings.TrimSpace(lon), 64)
+	if err != nil || longitude < -180 || longitude > 180 {
+		return Point{}, fmt.Errorf("longitude out of range: %q", lon)
+	}
+
+	return Point{Latitude: latitude, Longitude: longitude}, nil
+}
+
+// String formats the point as "latitude,longitude".
+func (p Point) String() string {
+	return fmt.Sprintf("%.6f,%.6f", p.Latitude, p.Longitude)
+}
+
+// DistanceKm returns the great-circle distance between two points in kilometers.
+func DistanceKm(a, b Point) float64 {
+	lat1 := a.Latitude * math.Pi / 180
+	lat2 := b.Latitude * math.Pi / 180
+	dLat := lat2 - lat1
+	dLon := (b.Longitude - a.Longitude) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Place is a named location from a gazetteer.
+type Place struct {
+	Name string
+	Point
+}
+
+// Gazetteer is a list of named places used for offline reverse geocoding.
+type Gazetteer struct {
+	Places []Place
+}
+
+// LoadGazetteer reads a CSV file with "name,latitude,longitude" rows. Blank
+// lines, lines starting with "#", and a header row are ignored.
+func LoadGazetteer(path string) (*Gazetteer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening gazetteer: %w", err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.Comment = '#'
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	g := &Gazetteer{}
+	for line := 1; ; line++ {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading gazetteer: %w", err)
+		}
+		if len(record) < 3 {
+			return nil, fmt.Errorf("gazetteer line %d: expected name, latitude, longitude", line)
+		}
+
+		p, err := newPoint(record[1], record[2])
+		if err != nil {
+			if line == 1 {
+				continue // header row
+			}
+			return nil, fmt.Errorf("gazetteer line %d: %w", line, err)
+		}
+
+		g.Places = append(g.Places, Place{Name: strings.TrimSpace(record[0]), Point: p})
+	}
+
+	return g, nil
+}
+
+// Nearest returns the place closest to p, if one lies within maxKm.
+func (g *Gazetteer) Nearest(p Point, maxKm float64) (Place, bool) {
+	var best Place
+	bestDistance := math.Inf(1)
+
+	for _, place := range g.Places {
+		if d := DistanceKm(p, place.Point); d < bestDistance {
+			best = place
+			bestDistance = d
+		}
+	}
+
+	if bestDistance > maxKm {
+		return Place{}, false
+	}
+	return best, true
+}
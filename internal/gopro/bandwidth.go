@@ -0,0 +1,177 @@
+package gopro
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+// Rate is a transfer rate in bytes per second. Zero means unlimited.
+type Rate uint64
+
+// ParseRate parses a human-readable transfer rate (e.g., "20MB/s" or
+// "500KiB/s"). The "/s" suffix is optional, and "unlimited" or "0" disables
+// the limit.
+func ParseRate(s string) (Rate, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.EqualFold(s, "unlimited") {
+		return 0, nil
+	}
+
+	n, err := humanize.ParseBytes(strings.TrimSuffix(s, "/s"))
+	if err != nil {
+		return 0, fmt.Errorf("invalid transfer rate: %q (use e.g. 20MB/s or unlimited)", s)
+	}
+	return Rate(n), nil
+}
+
+// String returns the rate in human-readable form.
+func (r Rate) String() string {
+	if r == 0 {
+		return "unlimited"
+	}
+	return humanize.Bytes(uint64(r)) + "/s"
+}
+
+// ParseTimeOfDay parses a local "HH:MM" time into its offset from midnight.
+func ParseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day: %q (use HH:MM)", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// RateWindow applies a rate between two times of day, given as offsets from
+// local midnight. A window ending before it starts wraps past midnight.
+type RateWindow struct {
+	Start time.Duration
+	End   time.Duration
+	Rate  Rate
+}
+
+// contains reports whether the window covers the given offset from midnight.
+func (w RateWindow) contains(offset time.Duration) bool {
+	if w.Start <= w.End {
+		return offset >= w.Start && offset < w.End
+	}
+	return offset >= w.Start || offset < w.End
+}
+
+// BandwidthSchedule picks the download rate by time of day. The first window
+// covering the current time wins; outside of every window, Default applies.
+type BandwidthSchedule struct {
+	Default Rate
+	Windows []RateWindow
+}
+
+// RateAt returns the rate in effect at the given time.
+func (s BandwidthSchedule) RateAt(t time.Time) Rate {
+	offset := time.Duration(t.Hour())*time.Hour +
+		time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second
+
+	for _, w := range s.Windows {
+		if w.contains(offset) {
+			return w.Rate
+		}
+	}
+	return s.Default
+}
+
+// RateLimiter is a token bucket capping the combined speed of every download
+// reading through it, following a bandwidth schedule. It holds at most one
+// second's worth of tokens, and is safe for concurrent use.
+type RateLimiter struct {
+	schedule BandwidthSchedule
+
+	mu     sync.Mutex
+	tokens float64 // guarded by mu; negative when reads are owed
+	last   time.Time
+}
+
+// NewRateLimiter creates a rate limiter following the given schedule.
+func NewRateLimiter(schedule BandwidthSchedule) *RateLimiter {
+	return &RateLimiter{schedule: schedule}
+}
+
+// WithRateLimiter throttles media downloads with the given limiter. Share one
+// limiter between clients to cap their downloads together.
+func WithRateLimiter(l *RateLimiter) Option {
+	return func(c *Client) { c.limiter = l }
+}
+
+// Reader returns r throttled by the limiter. Waiting for tokens stops early
+// with the context's error once ctx is done.
+func (l *RateLimiter) Reader(ctx context.Context, r io.Reader) io.Reader {
+	return &limitedReader{ctx: ctx, reader: r, limiter: l}
+}
+
+// reserve takes the tokens for reading up to n bytes, returning how many
+// bytes may be read and how long to wait before reading them.
+func (l *RateLimiter) reserve(n int) (int, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	rate := l.schedule.RateAt(now)
+	if rate == 0 {
+		return n, 0
+	}
+
+	burst := float64(rate)
+	l.tokens = min(l.tokens+now.Sub(l.last).Seconds()*burst, burst)
+	l.last = now
+
+	n = min(n, int(burst))
+	l.tokens -= float64(n)
+	if l.tokens >= 0 {
+		return n, 0
+	}
+	return n, time.Duration(-l.tokens / burst * float64(time.Second))
+}
+
+// refund returns the tokens for reserved bytes that weren't read.
+func (l *RateLimiter) refund(n int) {
+	if n <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens += float64(n)
+}
+
+// limitedReader waits for the limiter before each read, so that the wait
+// doesn't count as the underlying reader stalling.
+type limitedReader struct {
+	ctx     context.Context
+	reader  io.Reader
+	limiter *RateLimiter
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return r.reader.Read(p)
+	}
+
+	n, wait := r.limiter.reserve(len(p))
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-r.ctx.Done():
+			timer.Stop()
+			r.limiter.refund(n)
+			return 0, r.ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	read, err := r.reader.Read(p[:n])
+	r.limiter.refund(n - read)
+	return read, err
+}
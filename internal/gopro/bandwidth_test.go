@@ -0,0 +1,154 @@
+package gopro
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Rate
+		wantErr bool
+	}{
+		{input: "20MB/s", want: 20_000_000},
+		{input: "500KiB/s", want: 500 * 1024},
+		{input: "1.5MB", want: 1_500_000},
+		{input: " 100 kB/s ", want: 100_000},
+		{input: "unlimited", want: 0},
+		{input: "Unlimited", want: 0},
+		{input: "0", want: 0},
+		{input: "", want: 0},
+		{input: "fast", wantErr: true},
+		{input: "20MB/m", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseRate(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRate(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRate(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateAt(t *testing.T) {
+	schedule := BandwidthSchedule{
+		Default: 10,
+		Windows: []RateWindow{
+			{Start: 9 * time.Hour, End: 17 * time.Hour, Rate: 1},                // working hours
+			{Start: 23 * time.Hour, End: 6*time.Hour + 30*time.Minute, Rate: 0}, // overnight, unlimited
+			{Start: 16 * time.Hour, End: 18 * time.Hour, Rate: 5},               // overlaps the first window
+		},
+	}
+
+	tests := []struct {
+		clock string
+		want  Rate
+	}{
+		{clock: "08:59:59", want: 10},
+		{clock: "09:00:00", want: 1},
+		{clock: "16:30:00", want: 1}, // the first matching window wins
+		{clock: "17:00:00", want: 5},
+		{clock: "18:00:00", want: 10},
+		{clock: "23:00:00", want: 0},
+		{clock: "00:00:00", want: 0},
+		{clock: "06:29:59", want: 0},
+		{clock: "06:30:00", want: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.clock, func(t *testing.T) {
+			clock, err := time.Parse(time.TimeOnly, tt.clock)
+			if err != nil {
+				t.Fatal(err)
+			}
+			at := time.Date(2025, 6, 1, clock.Hour(), clock.Minute(), clock.Second(), 0, time.Local)
+
+			if got := schedule.RateAt(at); got != tt.want {
+				t.Errorf("RateAt(%s) = %d, want %d", tt.clock, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimiterReserve(t *testing.T) {
+	const rate = 1000
+
+	tests := []struct {
+		name     string
+		schedule BandwidthSchedule
+		reads    []int // reservations made before the checked one
+		request  int
+		wantN    int
+		wantWait time.Duration // upper bound; at most 10ms less
+	}{
+		{
+			name:     "unlimited",
+			schedule: BandwidthSchedule{},
+			reads:    []int{1 << 20},
+			request:  1 << 20,
+			wantN:    1 << 20,
+		},
+		{
+			name:     "within the initial burst",
+			schedule: BandwidthSchedule{Default: rate},
+			request:  600,
+			wantN:    600,
+		},
+		{
+			name:     "capped at one second's worth",
+			schedule: BandwidthSchedule{Default: rate},
+			request:  5000,
+			wantN:    rate,
+		},
+		{
+			name:     "waits for owed tokens",
+			schedule: BandwidthSchedule{Default: rate},
+			reads:    []int{600},
+			request:  600,
+			wantN:    600,
+			wantWait: 200 * time.Millisecond,
+		},
+		{
+			name:     "debt accumulates",
+			schedule: BandwidthSchedule{Default: rate},
+			reads:    []int{1000, 500},
+			request:  1000,
+			wantN:    rate,
+			wantWait: 1500 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewRateLimiter(tt.schedule)
+			for _, n := range tt.reads {
+				l.reserve(n)
+			}
+
+			n, wait := l.reserve(tt.request)
+			if n != tt.wantN {
+				t.Errorf("reserve(%d) n = %d, want %d", tt.request, n, tt.wantN)
+			}
+			if wait > tt.wantWait || wait < tt.wantWait-10*time.Millisecond {
+				t.Errorf("reserve(%d) wait = %s, want about %s", tt.request, wait, tt.wantWait)
+			}
+		})
+	}
+}
+
+func TestRateLimiterRefund(t *testing.T) {
+	l := NewRateLimiter(BandwidthSchedule{Default: 1000})
+	l.reserve(1000)
+	l.refund(400)
+
+	// The refunded tokens cover most of the next read.
+	if _, wait := l.reserve(500); wait > 100*time.Millisecond || wait < 90*time.Millisecond {
+		t.Errorf("reserve after refund wait = %s, want about 100ms", wait)
+	}
+}
@@ -6,7 +6,9 @@ package gopro
 
 import (
 	"context"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -14,15 +16,46 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
+	"github.com/hashicorp/go-cleanhttp"
 	"github.com/hashicorp/go-retryablehttp"
 )
 
 type Client struct {
-	httpClient *retryablehttp.Client
-	baseURL    *url.URL
-	logger     *slog.Logger
+	httpClients map[Operation]*retryablehttp.Client
+	policies    map[Operation]RequestPolicy
+	logger      *slog.Logger
+	host        string // as configured; empty means mDNS discovery
+	username    string
+	password    string
+	rootCAs     *x509.CertPool
+	pinnedCert  []byte
+	limiter     *RateLimiter // throttles downloads; nil means unlimited
+
+	mu          sync.RWMutex
+	baseURL     *url.URL // guarded by mu; changes when reconnecting
+	serial      string   // guarded by mu; identifies the camera when reconnecting
+	reconnectMu sync.Mutex
+}
+
+// Option configures optional behavior of a Client.
+type Option func(*Client)
+
+// WithBasicAuth sends the given credentials with every request, as required
+// by cameras using Camera on the Home Network (COHN).
+func WithBasicAuth(username, password string) Option {
+	return func(c *Client) {
+		c.username = username
+		c.password = password
+	}
+}
+
+// WithSerial sets the serial number of the expected camera, so that a camera
+// found at a new address after reconnecting is only used if it matches.
+func WithSerial(serial string) Option {
+	return func(c *Client) { c.serial = serial }
 }
 
 // progressWriter wraps an io.Reader to report download progress periodically.
@@ -43,34 +76,128 @@ func NewClientDefault(logger *slog.Logger) (*Client, error) {
 }
 
 // NewClient initializes a GoPro API client, resolving the address if necessary.
-func NewClient(logger *slog.Logger, scheme, host string) (*Client, error) {
+func NewClient(logger *slog.Logger, scheme, host string, opts ...Option) (*Client, error) {
 	baseURL, err := resolveGoPro(host, scheme)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize GoPro client: %w", err)
 	}
 
-	client := retryablehttp.NewClient()
-	client.Logger = logger
+	return newClient(logger, host, baseURL, opts), nil
+}
+
+// NewClientForSerial discovers the GoPros on the local network and returns a
+// client for the one with the given serial number.
+func NewClientForSerial(ctx context.Context, logger *slog.Logger, scheme, serial string, opts ...Option) (*Client, error) {
+	services, err := Discover(DefaultDiscoveryTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("auto-discovery failed: %w", err)
+	}
 
-	return &Client{
-		httpClient: client,
-		baseURL:    baseURL,
-		logger:     logger,
-	}, nil
+	for _, svc := range services {
+		baseURL, err := resolveGoPro(svc.addrFor(scheme), scheme)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize GoPro client: %w", err)
+		}
+		client := newClient(logger, "", baseURL, opts)
+		client.serial = serial
+
+		hwCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		hw, err := client.getHardwareInfo(hwCtx, client.probe, client.base())
+		cancel()
+		if err != nil {
+			logger.Debug("skipping unreachable GoPro", slog.String("address", svc.Addr()), slog.Any("error", err))
+			continue
+		}
+		if hw.SerialNumber == serial {
+			return client, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no GoPro with serial number %s found among %d discovered", serial, len(services))
+}
+
+func newClient(logger *slog.Logger, host string, baseURL *url.URL, opts []Option) *Client {
+	c := &Client{
+		policies: DefaultPolicies(),
+		baseURL:  baseURL,
+		logger:   logger,
+		host:     host,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	transport := cleanhttp.DefaultPooledTransport()
+	c.configureTLS(transport)
+	c.configureHTTPClients(transport)
+	return c
 }
 
 // BaseURL returns the GoPro's resolved base URL.
 func (c *Client) BaseURL() string {
-	return c.baseURL.String()
+	return c.base().String()
 }
 
-// get creates and performs a GET request, handling request creation, retries,
-// and error handling. It takes the FULL URL as a string.
+// base returns a copy of the GoPro's current base URL.
+func (c *Client) base() *url.URL {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	u := *c.baseURL
+	return &u
+}
+
+// get creates and performs a GET request with the query policy, handling
+// request creation, retries, and error handling. It takes the FULL URL as a
+// string.
 func (c *Client) get(ctx context.Context, fullURL string) (*http.Response, error) {
+	return c.request(ctx, OpQuery, fullURL)
+}
+
+// request performs a GET request with the operation's policy. If the camera
+// can't be reached, it looks for the camera at a new address and retries there.
+func (c *Client) request(ctx context.Context, op Operation, fullURL string) (*http.Response, error) {
+	return c.requestWithHeader(ctx, op, fullURL, nil)
+}
+
+// requestWithHeader is like request, additionally sending the given headers.
+func (c *Client) requestWithHeader(ctx context.Context, op Operation, fullURL string, header http.Header) (*http.Response, error) {
+	resp, err := c.do(ctx, op, fullURL, header)
+	if err == nil || ctx.Err() != nil || !isConnectionError(err) {
+		return resp, err
+	}
+
+	u, parseErr := url.Parse(fullURL)
+	if parseErr != nil {
+		return nil, err
+	}
+
+	newBase, reconnectErr := c.reconnect(ctx, u)
+	if reconnectErr != nil {
+		c.logger.Debug("failed to reconnect to GoPro", slog.Any("error", reconnectErr))
+		return nil, err
+	}
+
+	u.Scheme, u.Host = newBase.Scheme, newBase.Host
+	return c.do(ctx, op, u.String(), header)
+}
+
+// probe performs a query against the exact URL given, without reconnecting.
+func (c *Client) probe(ctx context.Context, fullURL string) (*http.Response, error) {
+	return c.do(ctx, OpQuery, fullURL, nil)
+}
+
+// do performs a GET request against the exact URL given.
+func (c *Client) do(ctx context.Context, op Operation, fullURL string, header http.Header) (*http.Response, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("creating request: %w", err)
 	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
 
 	// Wrap the *http.Request with retryablehttp.
 	retryableReq, err := retryablehttp.FromRequest(req)
@@ -78,7 +205,7 @@ func (c *Client) get(ctx context.Context, fullURL string) (*http.Response, error
 		return nil, fmt.Errorf("creating retryable request: %w", err)
 	}
 
-	return c.httpClient.Do(retryableReq)
+	return c.httpClients[op].Do(retryableReq)
 }
 
 // Upstream API: https://gopro.github.io/OpenGoPro/http#tag/Control/operation/OGP_TURBO_MODE_ENABLE
@@ -90,7 +217,7 @@ func (c *Client) ConfigureTurboTransfer(ctx context.Context, enable bool) error
 	}
 
 	// Create this manually as a string to prevent URL encoding.
-	fullURL := fmt.Sprintf("%s/gopro/media/turbo_transfer?p=%d", c.baseURL, param)
+	fullURL := fmt.Sprintf("%s/gopro/media/turbo_transfer?p=%d", c.base(), param)
 
 	resp, err := c.get(ctx, fullURL)
 	if err != nil {
@@ -108,7 +235,7 @@ func (c *Client) ConfigureTurboTransfer(ctx context.Context, enable bool) error
 
 // Upstream API: https://gopro.github.io/OpenGoPro/http#tag/Query/operation/OGP_GET_STATE
 func (c *Client) GetCameraState(ctx context.Context) (*CameraState, error) {
-	reqURL := c.baseURL.JoinPath("/gopro/camera/state").String()
+	reqURL := c.base().JoinPath("/gopro/camera/state").String()
 
 	resp, err := c.get(ctx, reqURL)
 	if err != nil {
@@ -129,11 +256,54 @@ func (c *Client) GetCameraState(ctx context.Context) (*CameraState, error) {
 	return &cameraState, nil
 }
 
+// WaitUntilReady polls the camera state until the camera is neither busy nor
+// encoding, giving up after timeout. It returns the last state fetched.
+func (c *Client) WaitUntilReady(ctx context.Context, timeout, interval time.Duration) (*CameraState, error) {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		state, err := c.GetCameraState(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if state.Status.Ready() {
+			return state, nil
+		}
+		if time.Now().Add(interval).After(deadline) {
+			return state, fmt.Errorf("camera still busy after %s", timeout)
+		}
+
+		c.logger.Info("waiting for camera to be ready", slog.Bool("busy", state.Status.Busy), slog.Bool("encoding", state.Status.Encoding))
+
+		select {
+		case <-ctx.Done():
+			return state, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
 // Upstream API: https://gopro.github.io/OpenGoPro/http#tag/Query/operation/OGP_CAMERA_INFO
 func (c *Client) GetHardwareInfo(ctx context.Context) (*HardwareInfo, error) {
-	reqURL := c.baseURL.JoinPath("/gopro/camera/info").String()
+	hwInfo, err := c.getHardwareInfo(ctx, c.get, c.base())
+	if err != nil {
+		return nil, err
+	}
 
-	resp, err := c.get(ctx, reqURL)
+	// Remember which camera this is, to recognize it after reconnecting.
+	c.mu.Lock()
+	if c.serial == "" {
+		c.serial = hwInfo.SerialNumber
+	}
+	c.mu.Unlock()
+
+	return hwInfo, nil
+}
+
+func (c *Client) getHardwareInfo(ctx context.Context, get func(context.Context, string) (*http.Response, error), base *url.URL) (*HardwareInfo, error) {
+	reqURL := base.JoinPath("/gopro/camera/info").String()
+
+	resp, err := get(ctx, reqURL)
 	if err != nil {
 		return nil, fmt.Errorf("getting hardware info: %w", err)
 	}
@@ -154,7 +324,7 @@ func (c *Client) GetHardwareInfo(ctx context.Context) (*HardwareInfo, error) {
 
 // Upstream API: https://gopro.github.io/OpenGoPro/http#tag/Media/operation/OGP_MEDIA_LIST
 func (c *Client) GetMediaList(ctx context.Context) (*MediaList, error) {
-	reqURL := c.baseURL.JoinPath("/gopro/media/list").String()
+	reqURL := c.base().JoinPath("/gopro/media/list").String()
 
 	resp, err := c.get(ctx, reqURL)
 	if err != nil {
@@ -188,20 +358,12 @@ func (c *Client) GetMediaList(ctx context.Context) (*MediaList, error) {
 }
 
 // Upstream API: https://gopro.github.io/OpenGoPro/http#tag/Media/operation/OGP_DOWNLOAD_MEDIA
+//
+// A transfer cut short after it started, e.g., by a dropped connection or a
+// stall, is resumed where it stopped, up to the download policy's retries.
 func (c *Client) DownloadMediaFile(ctx context.Context, directory string, filename string, downloadDir string) error {
 	relPath := fmt.Sprintf("/videos/DCIM/%s/%s", directory, filename)
-	reqURL := c.baseURL.JoinPath(relPath).String()
-
-	resp, err := c.get(ctx, reqURL)
-	if err != nil {
-		return fmt.Errorf("downloading media file: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("downloading media file: unexpected status code: %d, body: %s", resp.StatusCode, string(body))
-	}
+	reqURL := c.base().JoinPath(relPath).String()
 
 	absDownloadDir, err := filepath.Abs(downloadDir)
 	if err != nil {
@@ -219,37 +381,119 @@ func (c *Client) DownloadMediaFile(ctx context.Context, directory string, filena
 	}
 	defer out.Close()
 
+	policy := c.policies[OpDownload]
+	wait := policy.BackoffMin
+
+	var offset int64
+	for attempt := 0; ; attempt++ {
+		var resumable bool
+		offset, resumable, err = c.downloadFrom(ctx, reqURL, filename, out, offset)
+		if err == nil || !resumable || attempt >= policy.Retries {
+			return err
+		}
+
+		c.logger.Warn("download interrupted, resuming", slog.String("filename", filename), slog.Int64("offset", offset), slog.Any("error", err))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		wait = min(2*wait, max(policy.BackoffMax, policy.BackoffMin))
+	}
+}
+
+// downloadFrom streams the media file at reqURL into out, starting at offset.
+// The camera may ignore the requested range, in which case the file is
+// written again from the start. It returns the offset reached, and whether a
+// failure happened mid-transfer so that resuming from there may succeed.
+func (c *Client) downloadFrom(ctx context.Context, reqURL, filename string, out *os.File, offset int64) (int64, bool, error) {
+	reqCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var header http.Header
+	if offset > 0 {
+		header = http.Header{"Range": {fmt.Sprintf("bytes=%d-", offset)}}
+	}
+
+	resp, err := c.requestWithHeader(reqCtx, OpDownload, reqURL, header)
+	if err != nil {
+		return offset, false, fmt.Errorf("downloading media file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		// resuming
+	case resp.StatusCode == http.StatusOK:
+		offset = 0
+	default:
+		body, _ := io.ReadAll(resp.Body)
+		return offset, false, fmt.Errorf("downloading media file: unexpected status code: %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	if err := out.Truncate(offset); err != nil {
+		return offset, false, fmt.Errorf("writing to file: %w", err)
+	}
+	if _, err := out.Seek(offset, io.SeekStart); err != nil {
+		return offset, false, fmt.Errorf("writing to file: %w", err)
+	}
+
 	totalSize := resp.ContentLength
 	if totalSize <= 0 {
 		c.logger.Warn("Content-Length header not found or invalid, progress won't show total size.")
+	} else {
+		totalSize += offset
+	}
+
+	// Give up on the download if the camera stops sending data.
+	var body io.Reader = resp.Body
+	if timeout := c.policies[OpDownload].IdleTimeout; timeout > 0 {
+		idle := newIdleTimeoutReader(resp.Body, timeout, cancel)
+		defer idle.stop()
+		body = idle
+	}
+
+	// Throttle outside the idle timeout, which only runs while reading from the
+	// camera, so waiting on the limiter isn't mistaken for a stall.
+	if c.limiter != nil {
+		body = c.limiter.Reader(reqCtx, body)
 	}
 
 	progressReader := &progressWriter{
-		reader:     resp.Body,
+		reader:     body,
 		totalSize:  totalSize,
+		written:    offset,
 		logger:     c.logger,
 		interval:   5 * time.Second,
 		lastUpdate: time.Now(),
 		fileName:   filename,
 	}
 
-	_, err = io.Copy(out, progressReader)
+	n, err := io.Copy(out, progressReader)
+	offset += n
 	if err != nil {
 		if ctx.Err() != nil {
-			return ctx.Err()
+			return offset, false, ctx.Err()
+		}
+		if cause := context.Cause(reqCtx); errors.Is(cause, ErrStalled) {
+			return offset, true, fmt.Errorf("downloading media file: %w: no data received for %s", cause, c.policies[OpDownload].IdleTimeout)
 		}
-		return fmt.Errorf("writing to file: %w", err)
+		var pathErr *os.PathError
+		if errors.As(err, &pathErr) {
+			return offset, false, fmt.Errorf("writing to file: %w", err) // e.g., the disk is full
+		}
+		return offset, true, fmt.Errorf("downloading media file: %w", err)
 	}
 
-	return nil
+	return offset, false, nil
 }
 
 // Upstream API: https://gopro.github.io/OpenGoPro/http#tag/Media/operation/OGP_DELETE_SINGLE_FILE
 func (c *Client) DeleteSingleMediaFile(ctx context.Context, path string) error {
 	// Create this manually as a string to prevent URL encoding.
-	fullURL := fmt.Sprintf("%s/gopro/media/delete/file?path=%s", c.baseURL, path)
+	fullURL := fmt.Sprintf("%s/gopro/media/delete/file?path=%s", c.base(), path)
 
-	resp, err := c.get(ctx, fullURL)
+	resp, err := c.request(ctx, OpDelete, fullURL)
 	if err != nil {
 		return fmt.Errorf("deleting single media file: %w", err)
 	}
@@ -263,27 +507,108 @@ func (c *Client) DeleteSingleMediaFile(ctx context.Context, path string) error {
 	return nil
 }
 
-// Upstream API: https://gopro.github.io/OpenGoPro/http#tag/Query/operation/OGP_GET_DATE_AND_TIME_DST
+// GetDateTime returns the current time of the camera's clock.
+func (c *Client) GetDateTime(ctx context.Context) (time.Time, error) {
+	dt, err := c.getDateTime(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return dt.UTC()
+}
+
+// ClockDrift returns how far the camera's clock is ahead of the host's clock;
+// negative values mean the camera is behind. The camera only reports whole
+// seconds, so the result is accurate to about one second.
+func (c *Client) ClockDrift(ctx context.Context) (time.Duration, error) {
+	before := time.Now()
+	cameraTime, err := c.GetDateTime(ctx)
+	if err != nil {
+		return 0, err
+	}
+	after := time.Now()
+
+	hostTime := before.Add(after.Sub(before) / 2).Truncate(time.Second)
+	return cameraTime.Sub(hostTime), nil
+}
+
+// Upstream API: https://gopro.github.io/OpenGoPro/http#tag/Control/operation/OGP_SET_DATE_AND_TIME_DST_TZONE
+func (c *Client) SetDateTime(ctx context.Context, t time.Time) error {
+	_, offset := t.Zone()
+	dst := 0
+	if t.IsDST() {
+		dst = 1
+	}
+
+	// Create this manually as a string to prevent URL encoding.
+	fullURL := fmt.Sprintf("%s/gopro/camera/set_date_time?date=%s&time=%s&tzone=%d&dst=%d",
+		c.base(), t.Format("2006_01_02"), t.Format("15_04_05"), offset/60, dst)
+
+	resp, err := c.request(ctx, OpControl, fullURL)
+	if err != nil {
+		return fmt.Errorf("setting date and time: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("setting date and time: unexpected status code: %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
+
+// Upstream API: https://gopro.github.io/OpenGoPro/http#tag/settings
+func (c *Client) SetSetting(ctx context.Context, id SettingID, option int) error {
+	// Create this manually as a string to prevent URL encoding.
+	fullURL := fmt.Sprintf("%s/gopro/camera/setting?setting=%d&option=%d", c.base(), id, option)
+
+	resp, err := c.request(ctx, OpControl, fullURL)
+	if err != nil {
+		return fmt.Errorf("changing setting %d: %w", id, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusForbidden:
+		// The camera rejects options that conflict with its other settings.
+		return fmt.Errorf("changing setting %d: option %d not allowed in the camera's current state", id, option)
+	default:
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("changing setting %d: unexpected status code: %d, body: %s", id, resp.StatusCode, string(body))
+	}
+}
+
 func (c *Client) getTimezoneOffset(ctx context.Context) (int, error) {
-	reqURL := c.baseURL.JoinPath("/gopro/camera/get_date_time").String()
+	dt, err := c.getDateTime(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return dt.TZOffset, nil
+}
+
+// Upstream API: https://gopro.github.io/OpenGoPro/http#tag/Query/operation/OGP_GET_DATE_AND_TIME_DST
+func (c *Client) getDateTime(ctx context.Context) (*cameraDateTime, error) {
+	reqURL := c.base().JoinPath("/gopro/camera/get_date_time").String()
 
 	resp, err := c.get(ctx, reqURL)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return 0, fmt.Errorf("getting timezone offset: unexpected status code: %d, body: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("getting date and time: unexpected status code: %d, body: %s", resp.StatusCode, string(body))
 	}
 
 	var dt cameraDateTime
 	if err := json.NewDecoder(resp.Body).Decode(&dt); err != nil {
-		return 0, fmt.Errorf("decoding response: %w", err)
+		return nil, fmt.Errorf("decoding response: %w", err)
 	}
 
-	return dt.TZOffset, nil
+	return &dt, nil
 }
 
 // adjustTimestamps converts camera-local timestamps to UTC.
@@ -0,0 +1,63 @@
+package gopro
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDownloadMediaFileResumes(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 10000)
+
+	var requests atomic.Int32
+	var resumedFrom atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			// Drop the connection halfway through the first transfer.
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			w.WriteHeader(http.StatusOK)
+			w.Write(content[:len(content)/2])
+			conn, _, err := http.NewResponseController(w).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+			return
+		}
+		resumedFrom.Store(r.Header.Get("Range"))
+		http.ServeContent(w, r, "GX010001.MP4", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	client, err := NewClient(logger, "http", strings.TrimPrefix(server.URL, "http://"),
+		WithPolicy(OpDownload, RequestPolicy{Retries: 2, BackoffMin: time.Millisecond}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	if err := client.DownloadMediaFile(context.Background(), "100GOPRO", "GX010001.MP4", dir); err != nil {
+		t.Fatalf("DownloadMediaFile() error = %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, "GX010001.MP4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("downloaded %d bytes, want the %d bytes served", len(got), len(content))
+	}
+	if rng, _ := resumedFrom.Load().(string); !strings.HasPrefix(rng, "bytes=") || rng == "bytes=0-" {
+		t.Errorf("resumed with Range %q, want a range past the start", rng)
+	}
+}
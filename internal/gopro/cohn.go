@@ -0,0 +1,191 @@
+package gopro
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Cameras using Camera on the Home Network (COHN) serve HTTPS with a
+// certificate signed by their own self-signed root CA, and require basic
+// authentication.
+//
+// Upstream docs: https://gopro.github.io/OpenGoPro/ble/features/cohn.html
+
+// WithCACert trusts the camera's certificate if it chains to one of the
+// certificates in the PEM file at path. The host name isn't verified, as
+// COHN cameras are usually reached by a DHCP-assigned IP address.
+func WithCACert(path string) (Option, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading CA certificate: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+
+	return func(c *Client) { c.rootCAs = pool }, nil
+}
+
+// WithPinnedCert trusts only a camera whose certificate is, or chains to, the
+// certificate with the given SHA-256 fingerprint. The pinned certificate is
+// the sole trusted root, so a chain that merely includes it isn't enough.
+func WithPinnedCert(fingerprint []byte) Option {
+	return func(c *Client) { c.pinnedCert = fingerprint }
+}
+
+// ParseFingerprint decodes a SHA-256 certificate fingerprint written in hex,
+// optionally separated by colons.
+func ParseFingerprint(s string) ([]byte, error) {
+	fp, err := hex.DecodeString(strings.ReplaceAll(s, ":", ""))
+	if err != nil || len(fp) != sha256.Size {
+		return nil, fmt.Errorf("invalid SHA-256 fingerprint: %q", s)
+	}
+	return fp, nil
+}
+
+// Fingerprint returns the SHA-256 fingerprint of the certificate as
+// colon-separated hex.
+func Fingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":")
+}
+
+// FetchCertificates connects to the camera over TLS without verifying it and
+// returns the certificate chain it presents, leaf first. Hosts without a port
+// use 443, and an empty host uses mDNS discovery.
+func FetchCertificates(ctx context.Context, host string) ([]*x509.Certificate, error) {
+	addr, err := resolveHost(host, "https")
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve GoPro address: %w", err)
+	}
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "443")
+	}
+
+	dialer := &tls.Dialer{Config: &tls.Config{InsecureSkipVerify: true}}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to %s: %w", addr, err)
+	}
+	defer conn.Close()
+
+	certs := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificate presented by %s", addr)
+	}
+	return certs, nil
+}
+
+// FetchCertificatesForSerial discovers the GoPros on the local network and
+// returns the certificate chain of the one with the given serial number, as
+// FetchCertificates does. Each camera's serial number is checked over HTTPS
+// pinned to the certificate it presented, with the given options supplying
+// e.g. the credentials.
+func FetchCertificatesForSerial(ctx context.Context, logger *slog.Logger, serial string, opts ...Option) ([]*x509.Certificate, error) {
+	services, err := Discover(DefaultDiscoveryTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("auto-discovery failed: %w", err)
+	}
+
+	for _, svc := range services {
+		addr := svc.addrFor("https")
+		if addr == "" {
+			continue // no usable address
+		}
+
+		certs, err := FetchCertificates(ctx, addr)
+		if err != nil {
+			logger.Debug("skipping unreachable GoPro", slog.String("address", addr), slog.Any("error", err))
+			continue
+		}
+
+		sum := sha256.Sum256(certs[0].Raw)
+		client, err := NewClient(logger, "https", addr, append(opts, WithPinnedCert(sum[:]))...)
+		if err != nil {
+			return nil, err
+		}
+
+		hwCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		hw, err := client.getHardwareInfo(hwCtx, client.probe, client.base())
+		cancel()
+		if err != nil {
+			logger.Debug("skipping unreachable GoPro", slog.String("address", addr), slog.Any("error", err))
+			continue
+		}
+		if hw.SerialNumber == serial {
+			return certs, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no GoPro with serial number %s found among %d discovered", serial, len(services))
+}
+
+// configureTLS applies the trusted CA or pinned certificate, if any, to the
+// client's transport.
+func (c *Client) configureTLS(transport *http.Transport) {
+	if c.rootCAs == nil && c.pinnedCert == nil {
+		return
+	}
+
+	transport.TLSClientConfig = &tls.Config{
+		// Verification is done by verifyConnection instead.
+		InsecureSkipVerify: true,
+		VerifyConnection:   c.verifyConnection,
+	}
+}
+
+// verifyConnection checks the camera's certificate chain against the pinned
+// certificate or the trusted CA.
+func (c *Client) verifyConnection(cs tls.ConnectionState) error {
+	if len(cs.PeerCertificates) == 0 {
+		return fmt.Errorf("no certificate presented by camera")
+	}
+
+	roots := c.rootCAs
+	if c.pinnedCert != nil {
+		pinned := slices.IndexFunc(cs.PeerCertificates, func(cert *x509.Certificate) bool {
+			sum := sha256.Sum256(cert.Raw)
+			return bytes.Equal(sum[:], c.pinnedCert)
+		})
+		if pinned < 0 {
+			return fmt.Errorf("camera certificate doesn't match the pinned fingerprint")
+		}
+
+		// The pinned certificate may be the camera's root CA, which anyone
+		// can present, so the leaf must still chain to it.
+		roots = x509.NewCertPool()
+		roots.AddCert(cs.PeerCertificates[pinned])
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range cs.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := cs.PeerCertificates[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+	})
+	if err != nil {
+		return fmt.Errorf("verifying camera certificate: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package gopro
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestParseFingerprint(t *testing.T) {
+	const hexDigits = "00112233445566778899aabbccddeeff00112233445566778899aabbccddeeff"
+
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{name: "plain hex", input: hexDigits},
+		{name: "uppercase with colons", input: "00:11:22:33:44:55:66:77:88:99:AA:BB:CC:DD:EE:FF:00:11:22:33:44:55:66:77:88:99:AA:BB:CC:DD:EE:FF"},
+		{name: "too short", input: hexDigits[:62], wantErr: true},
+		{name: "too long", input: hexDigits + "00", wantErr: true},
+		{name: "not hex", input: "zz" + hexDigits[2:], wantErr: true},
+		{name: "empty", input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fp, err := ParseFingerprint(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFingerprint(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if err == nil && len(fp) != sha256.Size {
+				t.Errorf("ParseFingerprint(%q) returned %d bytes, want %d", tt.input, len(fp), sha256.Size)
+			}
+		})
+	}
+}
+
+// newTestCert creates a certificate signed by parent, or a self-signed one if
+// parent is nil.
+func newTestCert(t *testing.T, name string, isCA bool, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if isCA {
+		template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func TestVerifyConnection(t *testing.T) {
+	root, rootKey := newTestCert(t, "GoPro Root CA", true, nil, nil)
+	leaf, _ := newTestCert(t, "GoPro Camera", false, root, rootKey)
+	forged, _ := newTestCert(t, "GoPro Camera", false, nil, nil)
+
+	fingerprint := func(cert *x509.Certificate) []byte {
+		sum := sha256.Sum256(cert.Raw)
+		return sum[:]
+	}
+	rootPool := x509.NewCertPool()
+	rootPool.AddCert(root)
+
+	tests := []struct {
+		name    string
+		client  *Client
+		chain   []*x509.Certificate
+		wantErr bool
+	}{
+		{
+			name:   "pinned root signing the leaf",
+			client: &Client{pinnedCert: fingerprint(root)},
+			chain:  []*x509.Certificate{leaf, root},
+		},
+		{
+			name:   "pinned leaf",
+			client: &Client{pinnedCert: fingerprint(leaf)},
+			chain:  []*x509.Certificate{leaf, root},
+		},
+		{
+			name:    "pinned root presented next to a forged leaf",
+			client:  &Client{pinnedCert: fingerprint(root)},
+			chain:   []*x509.Certificate{forged, root},
+			wantErr: true,
+		},
+		{
+			name:    "pinned certificate missing",
+			client:  &Client{pinnedCert: fingerprint(root)},
+			chain:   []*x509.Certificate{forged},
+			wantErr: true,
+		},
+		{
+			name:   "trusted CA signing the leaf",
+			client: &Client{rootCAs: rootPool},
+			chain:  []*x509.Certificate{leaf},
+		},
+		{
+			name:    "trusted CA presented next to a forged leaf",
+			client:  &Client{rootCAs: rootPool},
+			chain:   []*x509.Certificate{forged, root},
+			wantErr: true,
+		},
+		{
+			name:    "no certificate",
+			client:  &Client{pinnedCert: fingerprint(root)},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.client.verifyConnection(tls.ConnectionState{PeerCertificates: tt.chain})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("verifyConnection() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package gopro
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// PresetGroup identifies one of the camera's modes, each holding presets.
+type PresetGroup int
+
+const (
+	PresetGroupVideo     PresetGroup = 1000
+	PresetGroupPhoto     PresetGroup = 1001
+	PresetGroupTimelapse PresetGroup = 1002
+)
+
+// PresetGroups maps mode names to their preset groups.
+var PresetGroups = map[string]PresetGroup{
+	"video":     PresetGroupVideo,
+	"photo":     PresetGroupPhoto,
+	"timelapse": PresetGroupTimelapse,
+}
+
+// Preset is a single capture preset.
+type Preset struct {
+	ID          int    `json:"id"`
+	CustomName  string `json:"customName"`
+	UserDefined bool   `json:"userDefined"`
+}
+
+// presetStatus is the response from the presets API.
+type presetStatus struct {
+	Groups []struct {
+		Presets []Preset `json:"presetArray"`
+	} `json:"presetGroupArray"`
+}
+
+// Upstream API: https://gopro.github.io/OpenGoPro/http#tag/Control/operation/OGP_SHUTTER_START
+func (c *Client) StartShutter(ctx context.Context) error {
+	return c.control(ctx, "/gopro/camera/shutter/start", "starting shutter")
+}
+
+// Upstream API: https://gopro.github.io/OpenGoPro/http#tag/Control/operation/OGP_SHUTTER_STOP
+func (c *Client) StopShutter(ctx context.Context) error {
+	return c.control(ctx, "/gopro/camera/shutter/stop", "stopping shutter")
+}
+
+// Upstream API: https://gopro.github.io/OpenGoPro/http#tag/Control/operation/OGP_KEEP_ALIVE
+func (c *Client) KeepAlive(ctx context.Context) error {
+	return c.control(ctx, "/gopro/camera/keep_alive", "sending keep-alive")
+}
+
+// Sleep puts the camera to sleep. The Open GoPro HTTP API has no sleep command,
+// so this uses the legacy GoPro API, which cameras running Open GoPro (HERO9
+// and later) answer with 404 Not Found.
+func (c *Client) Sleep(ctx context.Context) error {
+	return c.control(ctx, "/gp/gpControl/command/system/sleep", "putting camera to sleep")
+}
+
+// Upstream API: https://gopro.github.io/OpenGoPro/http#tag/Presets/operation/OGP_PRESET_LOAD
+func (c *Client) LoadPreset(ctx context.Context, id int) error {
+	return c.control(ctx, fmt.Sprintf("/gopro/camera/presets/load?id=%d", id), "loading preset")
+}
+
+// Upstream API: https://gopro.github.io/OpenGoPro/http#tag/Presets/operation/OGP_PRESET_SET_GROUP
+func (c *Client) LoadPresetGroup(ctx context.Context, group PresetGroup) error {
+	return c.control(ctx, fmt.Sprintf("/gopro/camera/presets/set_group?id=%d", group), "loading preset group")
+}
+
+// Upstream API: https://gopro.github.io/OpenGoPro/http#tag/Presets/operation/OGP_PRESETS_GET
+func (c *Client) GetPresets(ctx context.Context) ([]Preset, error) {
+	reqURL := c.base().JoinPath("/gopro/camera/presets/get").String()
+
+	resp, err := c.get(ctx, reqURL)
+	if err != nil {
+		return nil, fmt.Errorf("getting presets: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := controlStatus(resp); err != nil {
+		return nil, fmt.Errorf("getting presets: %w", err)
+	}
+
+	var status presetStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+
+	var presets []Preset
+	for _, group := range status.Groups {
+		presets = append(presets, group.Presets...)
+	}
+	return presets, nil
+}
+
+// FindPreset returns the preset with the given custom name, matched
+// case-insensitively.
+func FindPreset(presets []Preset, name string) (Preset, bool) {
+	for _, p := range presets {
+		if p.CustomName != "" && strings.EqualFold(p.CustomName, name) {
+			return p, true
+		}
+	}
+	return Preset{}, false
+}
+
+// control sends a command that returns no data. Commands aren't retried, as
+// repeating e.g. a shutter press isn't harmless.
+func (c *Client) control(ctx context.Context, path, action string) error {
+	// Create this manually as a string to prevent URL encoding.
+	resp, err := c.request(ctx, OpControl, c.base().String()+path)
+	if err != nil {
+		return fmt.Errorf("%s: %w", action, err)
+	}
+	defer resp.Body.Close()
+
+	if err := controlStatus(resp); err != nil {
+		return fmt.Errorf("%s: %w", action, err)
+	}
+	return nil
+}
+
+// controlStatus converts an unsuccessful response into an error, wrapping
+// errors.ErrUnsupported if the camera doesn't know the endpoint.
+func controlStatus(resp *http.Response) error {
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return fmt.Errorf("not supported by this camera model: %w", errors.ErrUnsupported)
+	case http.StatusForbidden, http.StatusConflict:
+		return fmt.Errorf("rejected by the camera in its current state (status %d)", resp.StatusCode)
+	default:
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(body))
+	}
+}
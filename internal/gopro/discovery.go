@@ -4,9 +4,14 @@ import (
 	"fmt"
 	"net"
 	"net/url"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/miekg/dns"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
 )
 
 // resolveGoPro determines the GoPro's IP address, using mDNS or DNS resolution if necessary.
@@ -20,7 +25,7 @@ func resolveGoPro(host, scheme string) (*url.URL, error) {
 	}
 
 	// Resolve host to an IP address if needed.
-	resolvedHost, err := resolveHost(host)
+	resolvedHost, err := resolveHost(host, scheme)
 	if err != nil {
 		return nil, fmt.Errorf("could not resolve GoPro address: %w", err)
 	}
@@ -32,14 +37,14 @@ func resolveGoPro(host, scheme string) (*url.URL, error) {
 }
 
 // resolveHost ensures the returned address is an IP while preserving the port.
-func resolveHost(host string) (string, error) {
+// An empty host is auto-discovered via DNS-SD and addressed for the scheme.
+func resolveHost(host, scheme string) (string, error) {
 	if host == "" {
-		// Auto-discover GoPro via mDNS and use the default API port.
-		ip, err := findGoPro()
+		svc, err := findGoPro()
 		if err != nil {
 			return "", fmt.Errorf("auto-discovery failed: %w", err)
 		}
-		return net.JoinHostPort(ip.String(), "8080"), nil
+		return svc.addrFor(scheme), nil
 	}
 
 	// Parse as URL to extract hostname and port correctly.
@@ -66,85 +71,365 @@ func resolveHost(host string) (string, error) {
 	return hostname, nil
 }
 
-// findGoPro discovers a GoPro camera on the local network via mDNS.
-func findGoPro() (net.IP, error) {
-	multicastAddr := "224.0.0.251:5353"
+// serviceType is the DNS-SD service advertised by cameras serving the Open
+// GoPro HTTP API.
+const serviceType = "_gopro-web._tcp.local."
+
+// DefaultDiscoveryTimeout is how long Discover listens for cameras.
+const DefaultDiscoveryTimeout = 3 * time.Second
+
+// Service is a GoPro found via DNS-SD.
+type Service struct {
+	Instance string            // instance name, e.g. "GoPro 1234"
+	Host     string            // target host name from the SRV record
+	Port     int               // advertised port of the HTTP API
+	IPs      []net.IP          // IPv4 addresses first, then IPv6
+	TXT      map[string]string // key/value pairs from the TXT record
+}
+
+// Addr returns the host:port to reach the service at, preferring IPv4.
+// Link-local IPv6 addresses are skipped as they need an interface zone.
+func (s Service) Addr() string {
+	for _, ip := range s.IPs {
+		if ip.To4() != nil || !ip.IsLinkLocalUnicast() {
+			return net.JoinHostPort(ip.String(), strconv.Itoa(s.Port))
+		}
+	}
+	return ""
+}
+
+// addrFor returns the host:port to reach the service at with the given
+// scheme. The advertised port belongs to the HTTP API, so HTTPS uses 443.
+func (s Service) addrFor(scheme string) string {
+	if scheme != "https" {
+		return s.Addr()
+	}
+	hostname, _, err := net.SplitHostPort(s.Addr())
+	if err != nil {
+		return ""
+	}
+	return net.JoinHostPort(hostname, "443")
+}
 
-	// Use a standard UDP socket for sending.
-	dst, err := net.ResolveUDPAddr("udp4", multicastAddr)
+// findGoPro discovers a GoPro camera on the local network via mDNS.
+func findGoPro() (Service, error) {
+	services, err := browse(6*time.Second, 1)
 	if err != nil {
-		return nil, fmt.Errorf("failed to resolve multicast address: %w", err)
+		return Service{}, err
+	}
+	return services[0], nil
+}
+
+// Discover browses the local network for GoPro cameras via DNS-SD, listening
+// for the full timeout to find every camera.
+func Discover(timeout time.Duration) ([]Service, error) {
+	return browse(timeout, 0)
+}
+
+// browse sends DNS-SD queries on every multicast interface, following each
+// PTR answer with SRV/TXT and A/AAAA queries until the services are resolved.
+// It returns as soon as limit services are resolved; a limit of zero waits for
+// the full timeout.
+func browse(timeout time.Duration, limit int) ([]Service, error) {
+	var conns []*mdnsConn
+	for _, network := range []string{"udp4", "udp6"} {
+		c, err := listenMDNS(network)
+		if err != nil {
+			continue // e.g., IPv6 disabled
+		}
+		defer c.Close()
+		conns = append(conns, c)
+	}
+	if len(conns) == 0 {
+		return nil, fmt.Errorf("failed to open UDP socket")
+	}
+
+	deadline := time.Now().Add(timeout)
+	msgs := make(chan *dns.Msg, 64)
+	for _, c := range conns {
+		go c.receive(deadline, msgs)
+	}
+
+	r := newResolver()
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+
+	// Send the first round immediately, then follow up on anything pending.
+	send := func() error {
+		questions := r.pending()
+		if len(questions) == 0 {
+			return nil
+		}
+		var sent bool
+		for _, c := range conns {
+			if c.query(questions) == nil {
+				sent = true
+			}
+		}
+		if !sent {
+			return fmt.Errorf("failed to send query")
+		}
+		return nil
+	}
+	if err := send(); err != nil {
+		return nil, err
+	}
+
+loop:
+	for {
+		select {
+		case msg := <-msgs:
+			r.add(msg)
+			if limit > 0 && len(r.services()) >= limit {
+				break loop
+			}
+		case <-ticker.C:
+			send()
+		case <-timer.C:
+			break loop
+		}
+	}
+
+	services := r.services()
+	if len(services) == 0 {
+		return nil, fmt.Errorf("no GoPro responded within %s", timeout)
 	}
+	if limit > 0 && len(services) > limit {
+		services = services[:limit]
+	}
+	return services, nil
+}
+
+// mdnsConn is a socket for sending mDNS queries and receiving the unicast
+// replies that responders send to queriers not bound to port 5353.
+type mdnsConn struct {
+	net.PacketConn
+	group    *net.UDPAddr
+	setIface func(*net.Interface) error
+}
 
-	conn, err := net.ListenPacket("udp4", ":0") // bind to an ephemeral port
+func listenMDNS(network string) (*mdnsConn, error) {
+	conn, err := net.ListenPacket(network, ":0") // bind to an ephemeral port
 	if err != nil {
-		return nil, fmt.Errorf("failed to open UDP socket: %w", err)
+		return nil, err
 	}
-	defer conn.Close()
 
-	// Build the mDNS query.
+	c := &mdnsConn{PacketConn: conn}
+	if network == "udp4" {
+		c.group = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+		c.setIface = ipv4.NewPacketConn(conn).SetMulticastInterface
+	} else {
+		c.group = &net.UDPAddr{IP: net.ParseIP("ff02::fb"), Port: 5353}
+		c.setIface = ipv6.NewPacketConn(conn).SetMulticastInterface
+	}
+	return c, nil
+}
+
+// query sends the questions out of every multicast-capable interface.
+func (c *mdnsConn) query(questions []dns.Question) error {
 	msg := new(dns.Msg)
-	msg.SetQuestion("_gopro-web._tcp.local.", dns.TypePTR)
+	msg.Id = dns.Id()
 	msg.RecursionDesired = false
+	msg.Question = questions
 
 	buf, err := msg.Pack()
 	if err != nil {
-		return nil, fmt.Errorf("failed to pack message: %w", err)
+		return fmt.Errorf("failed to pack message: %w", err)
+	}
+
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return fmt.Errorf("listing interfaces: %w", err)
+	}
+
+	var sent bool
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagMulticast == 0 {
+			continue
+		}
+		if err := c.setIface(&iface); err != nil {
+			continue // e.g., no address of this family
+		}
+		if _, err := c.WriteTo(buf, c.group); err == nil {
+			sent = true
+		}
 	}
 
-	// Set up a channel for the response.
-	resultChan := make(chan net.IP, 1)
-	doneChan := make(chan struct{})
+	// Fall back to the default route if no interface accepted the query.
+	if !sent {
+		if _, err := c.WriteTo(buf, c.group); err != nil {
+			return fmt.Errorf("failed to send query: %w", err)
+		}
+	}
+	return nil
+}
 
-	// Listen for responses.
-	go func() {
-		response := make([]byte, 65536)
-		conn.SetReadDeadline(time.Now().Add(6 * time.Second))
+// receive forwards every DNS message received before the deadline.
+func (c *mdnsConn) receive(deadline time.Time, msgs chan<- *dns.Msg) {
+	c.SetReadDeadline(deadline)
+	buf := make([]byte, 65536)
 
-		for {
-			n, _, err := conn.ReadFrom(response)
-			if err != nil {
-				close(doneChan)
-				return
-			}
+	for {
+		n, _, err := c.ReadFrom(buf)
+		if err != nil {
+			return
+		}
 
-			resp := new(dns.Msg)
-			if err := resp.Unpack(response[:n]); err != nil {
-				continue
+		msg := new(dns.Msg)
+		if err := msg.Unpack(buf[:n]); err != nil {
+			continue
+		}
+
+		select {
+		case msgs <- msg:
+		default: // drop if the browser has stopped listening
+		}
+	}
+}
+
+// resolver collects DNS-SD records across responses. Names are compared
+// case-insensitively.
+type resolver struct {
+	instances []string
+	srv       map[string]*dns.SRV
+	txt       map[string][]string
+	ips       map[string][]net.IP
+}
+
+func newResolver() *resolver {
+	return &resolver{
+		srv: make(map[string]*dns.SRV),
+		txt: make(map[string][]string),
+		ips: make(map[string][]net.IP),
+	}
+}
+
+// add records every relevant answer and additional record of msg.
+func (r *resolver) add(msg *dns.Msg) {
+	for _, rr := range append(msg.Answer, msg.Extra...) {
+		name := strings.ToLower(rr.Header().Name)
+
+		switch rr := rr.(type) {
+		case *dns.PTR:
+			if name == serviceType && !slices.Contains(r.instances, strings.ToLower(rr.Ptr)) {
+				r.instances = append(r.instances, strings.ToLower(rr.Ptr))
 			}
+		case *dns.SRV:
+			r.srv[name] = rr
+		case *dns.TXT:
+			r.txt[name] = rr.Txt
+		case *dns.A:
+			r.addIP(name, rr.A)
+		case *dns.AAAA:
+			r.addIP(name, rr.AAAA)
+		}
+	}
+}
+
+func (r *resolver) addIP(host string, ip net.IP) {
+	if !slices.ContainsFunc(r.ips[host], ip.Equal) {
+		r.ips[host] = append(r.ips[host], ip)
+	}
+}
+
+// pending returns the questions still needed to resolve every instance.
+func (r *resolver) pending() []dns.Question {
+	questions := []dns.Question{question(serviceType, dns.TypePTR)}
+
+	for _, instance := range r.instances {
+		srv, ok := r.srv[instance]
+		if !ok {
+			questions = append(questions, question(instance, dns.TypeSRV))
+		}
+		if _, ok := r.txt[instance]; !ok {
+			questions = append(questions, question(instance, dns.TypeTXT))
+		}
+		if ok && len(r.ips[strings.ToLower(srv.Target)]) == 0 {
+			questions = append(questions,
+				question(srv.Target, dns.TypeA),
+				question(srv.Target, dns.TypeAAAA))
+		}
+	}
+	return questions
+}
+
+func question(name string, qtype uint16) dns.Question {
+	return dns.Question{Name: name, Qtype: qtype, Qclass: dns.ClassINET}
+}
+
+// services returns the instances that have been resolved to an address.
+func (r *resolver) services() []Service {
+	var services []Service
+	for _, instance := range r.instances {
+		srv, ok := r.srv[instance]
+		if !ok {
+			continue
+		}
 
-			// Look for A records.
-			for _, answer := range append(resp.Answer, resp.Extra...) {
-				if a, ok := answer.(*dns.A); ok {
-					resultChan <- a.A
-					close(doneChan)
-					return
+		s := Service{
+			Instance: instanceName(srv.Hdr.Name),
+			Host:     srv.Target,
+			Port:     int(srv.Port),
+			TXT:      parseTXT(r.txt[instance]),
+		}
+
+		// List IPv4 addresses first.
+		for _, v4 := range []bool{true, false} {
+			for _, ip := range r.ips[strings.ToLower(srv.Target)] {
+				if (ip.To4() != nil) == v4 {
+					s.IPs = append(s.IPs, ip)
 				}
 			}
 		}
-	}()
 
-	// Send query and retry up to 3 times, but stop if a response is received.
-	for range 3 {
-		select {
-		case ip := <-resultChan:
-			return ip, nil
-		case <-time.After(500 * time.Millisecond):
-			if _, err := conn.WriteTo(buf, dst); err != nil {
-				return nil, fmt.Errorf("failed to send query: %w", err)
+		if s.Addr() != "" {
+			services = append(services, s)
+		}
+	}
+	return services
+}
+
+// instanceName returns the first label of a service instance name, with
+// escaped characters (e.g., "\ " or "\032") decoded.
+func instanceName(name string) string {
+	labels := dns.SplitDomainName(name)
+	if len(labels) == 0 {
+		return name
+	}
+
+	label := labels[0]
+	var b strings.Builder
+	for i := 0; i < len(label); i++ {
+		if label[i] != '\\' || i+1 == len(label) {
+			b.WriteByte(label[i])
+			continue
+		}
+		if i+3 < len(label) {
+			if n, err := strconv.Atoi(label[i+1 : i+4]); err == nil && n < 256 {
+				b.WriteByte(byte(n))
+				i += 3
+				continue
 			}
-		case <-doneChan:
-			break
 		}
+		b.WriteByte(label[i+1])
+		i++
 	}
+	return b.String()
+}
 
-	// Final check in case response came in right before timeout.
-	select {
-	case ip := <-resultChan:
-		return ip, nil
-	default:
-		return nil, fmt.Errorf("no response received after retries")
+// parseTXT converts TXT strings of the form key=value into a map. Keys
+// without a value map to an empty string.
+func parseTXT(txt []string) map[string]string {
+	fields := make(map[string]string, len(txt))
+	for _, entry := range txt {
+		key, value, _ := strings.Cut(entry, "=")
+		if key != "" {
+			fields[key] = value
+		}
 	}
+	return fields
 }
 
 // resolveIPv4 looks up the first IPv4 address for a hostname.
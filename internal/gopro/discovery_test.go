@@ -0,0 +1,226 @@
+package gopro
+
+import (
+	"maps"
+	"net"
+	"slices"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+func TestInstanceName(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: `GoPro\ 1234._gopro-web._tcp.local.`, want: "GoPro 1234"},
+		{input: `GoPro\0321234._gopro-web._tcp.local.`, want: "GoPro 1234"},
+		{input: `Aaron\'s\ HERO12._gopro-web._tcp.local.`, want: "Aaron's HERO12"},
+		{input: `dot\.name._gopro-web._tcp.local.`, want: "dot.name"},
+		{input: `trailing\`, want: `trailing\`},
+		{input: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := instanceName(tt.input); got != tt.want {
+				t.Errorf("instanceName(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+// Records of a single camera advertising its HTTP API.
+const (
+	testInstance = `GoPro\ 1234._gopro-web._tcp.local.`
+	testTarget   = "gopro-1234.local."
+)
+
+func testPTR(instance string) dns.RR {
+	return &dns.PTR{Hdr: dns.RR_Header{Name: serviceType, Rrtype: dns.TypePTR, Class: dns.ClassINET}, Ptr: instance}
+}
+
+func testSRV(instance, target string, port uint16) dns.RR {
+	return &dns.SRV{Hdr: dns.RR_Header{Name: instance, Rrtype: dns.TypeSRV, Class: dns.ClassINET}, Target: target, Port: port}
+}
+
+func testTXT(instance string, txt ...string) dns.RR {
+	return &dns.TXT{Hdr: dns.RR_Header{Name: instance, Rrtype: dns.TypeTXT, Class: dns.ClassINET}, Txt: txt}
+}
+
+func testA(host, ip string) dns.RR {
+	return &dns.A{Hdr: dns.RR_Header{Name: host, Rrtype: dns.TypeA, Class: dns.ClassINET}, A: net.ParseIP(ip)}
+}
+
+func testAAAA(host, ip string) dns.RR {
+	return &dns.AAAA{Hdr: dns.RR_Header{Name: host, Rrtype: dns.TypeAAAA, Class: dns.ClassINET}, AAAA: net.ParseIP(ip)}
+}
+
+func TestResolverServices(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages [][]dns.RR // answers of each response, in arrival order
+		extras   []dns.RR   // additional records of the first response
+		want     []Service
+	}{
+		{
+			name: "everything in one response",
+			messages: [][]dns.RR{{
+				testPTR(testInstance),
+			}},
+			extras: []dns.RR{
+				testSRV(testInstance, testTarget, 8080),
+				testTXT(testInstance, "model=HERO12", "flag"),
+				testA(testTarget, "192.168.1.50"),
+			},
+			want: []Service{{
+				Instance: "GoPro 1234",
+				Host:     testTarget,
+				Port:     8080,
+				IPs:      []net.IP{net.ParseIP("192.168.1.50")},
+				TXT:      map[string]string{"model": "HERO12", "flag": ""},
+			}},
+		},
+		{
+			name: "records across responses with mixed case",
+			messages: [][]dns.RR{
+				{testPTR(`GOPRO\ 1234._gopro-web._tcp.local.`)},
+				{testSRV(testInstance, "GoPro-1234.local.", 8080)},
+				{testAAAA("gopro-1234.local.", "2001:db8::50"), testA("gopro-1234.local.", "192.168.1.50")},
+				{testA(testTarget, "192.168.1.50")}, // repeated
+			},
+			want: []Service{{
+				Instance: "GoPro 1234",
+				Host:     "GoPro-1234.local.",
+				Port:     8080,
+				IPs:      []net.IP{net.ParseIP("192.168.1.50"), net.ParseIP("2001:db8::50")},
+				TXT:      map[string]string{},
+			}},
+		},
+		{
+			name: "unresolved address",
+			messages: [][]dns.RR{
+				{testPTR(testInstance), testSRV(testInstance, testTarget, 8080)},
+			},
+			want: nil,
+		},
+		{
+			name: "only link-local IPv6",
+			messages: [][]dns.RR{
+				{testPTR(testInstance), testSRV(testInstance, testTarget, 8080), testAAAA(testTarget, "fe80::1")},
+			},
+			want: nil,
+		},
+		{
+			name: "other service types",
+			messages: [][]dns.RR{{
+				&dns.PTR{Hdr: dns.RR_Header{Name: "_http._tcp.local.", Rrtype: dns.TypePTR}, Ptr: "Printer._http._tcp.local."},
+				testSRV("Printer._http._tcp.local.", "printer.local.", 80),
+				testA("printer.local.", "192.168.1.9"),
+			}},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newResolver()
+			for i, answers := range tt.messages {
+				msg := &dns.Msg{Answer: answers}
+				if i == 0 {
+					msg.Extra = tt.extras
+				}
+				r.add(msg)
+			}
+
+			got := r.services()
+			if len(got) != len(tt.want) {
+				t.Fatalf("services() = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if !equalService(got[i], tt.want[i]) {
+					t.Errorf("services()[%d] = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestResolverPending(t *testing.T) {
+	r := newResolver()
+	r.add(&dns.Msg{Answer: []dns.RR{testPTR(testInstance)}})
+
+	// The instance still needs its SRV and TXT records.
+	if got := questionTypes(r.pending()); !slices.Equal(got, []uint16{dns.TypePTR, dns.TypeSRV, dns.TypeTXT}) {
+		t.Errorf("pending() after PTR = %v", got)
+	}
+
+	// Then the addresses of its target.
+	r.add(&dns.Msg{Answer: []dns.RR{testSRV(testInstance, testTarget, 8080), testTXT(testInstance)}})
+	if got := questionTypes(r.pending()); !slices.Equal(got, []uint16{dns.TypePTR, dns.TypeA, dns.TypeAAAA}) {
+		t.Errorf("pending() after SRV and TXT = %v", got)
+	}
+
+	// Browsing for more cameras continues.
+	r.add(&dns.Msg{Answer: []dns.RR{testA(testTarget, "192.168.1.50")}})
+	if got := questionTypes(r.pending()); !slices.Equal(got, []uint16{dns.TypePTR}) {
+		t.Errorf("pending() after A = %v", got)
+	}
+}
+
+func questionTypes(questions []dns.Question) []uint16 {
+	types := make([]uint16, len(questions))
+	for i, q := range questions {
+		types[i] = q.Qtype
+	}
+	return types
+}
+
+func equalService(a, b Service) bool {
+	return a.Instance == b.Instance &&
+		a.Host == b.Host &&
+		a.Port == b.Port &&
+		slices.EqualFunc(a.IPs, b.IPs, net.IP.Equal) &&
+		maps.Equal(a.TXT, b.TXT)
+}
+
+func TestServiceBaseURL(t *testing.T) {
+	tests := []struct {
+		name   string
+		scheme string
+		svc    Service
+		want   string
+	}{
+		{
+			name:   "http keeps the advertised port",
+			scheme: "http",
+			svc:    Service{Port: 8080, IPs: []net.IP{net.ParseIP("192.168.1.50")}},
+			want:   "http://192.168.1.50:8080",
+		},
+		{
+			name:   "https uses its own port",
+			scheme: "https",
+			svc:    Service{Port: 8080, IPs: []net.IP{net.ParseIP("192.168.1.50")}},
+			want:   "https://192.168.1.50:443",
+		},
+		{
+			name:   "https over IPv6",
+			scheme: "https",
+			svc:    Service{Port: 8080, IPs: []net.IP{net.ParseIP("fe80::1"), net.ParseIP("2001:db8::50")}},
+			want:   "https://[2001:db8::50]:443",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := resolveGoPro(tt.svc.addrFor(tt.scheme), tt.scheme)
+			if err != nil {
+				t.Fatalf("resolveGoPro() error = %v", err)
+			}
+			if got := u.String(); got != tt.want {
+				t.Errorf("base URL = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -21,12 +21,44 @@ type HardwareInfo struct {
 
 // CameraState represents the top-level response from the camera state API.
 type CameraState struct {
-	Status cameraStateStatus `json:"status"`
+	Status   cameraStateStatus `json:"status"`
+	Settings map[SettingID]int `json:"settings"`
+}
+
+// Setting returns the current value of the setting, if the camera reported it.
+func (s *CameraState) Setting(id SettingID) (int, bool) {
+	value, ok := s.Settings[id]
+	return value, ok
 }
 
 type cameraStateStatus struct {
 	SDCardCapacity  int64 `json:"117"`
 	SDCardRemaining int64 `json:"54"`
+	BatteryBars     int   `json:"2"`   // 0-3 bars, or 4 while charging
+	BatteryPercent  int   `json:"70"`  // internal battery level
+	Busy            bool  `json:"8"`   // busy with another operation
+	Encoding        bool  `json:"10"`  // recording or otherwise encoding
+	SystemHot       bool  `json:"6"`   // too hot to operate normally
+	TooCold         bool  `json:"85"`  // too cold to record
+	USBConnected    bool  `json:"115"` // connected to USB power or a computer
+}
+
+// batteryCharging is the battery bars value reported while charging.
+const batteryCharging = 4
+
+// Ready reports whether the camera is free to handle a transfer.
+func (c *cameraStateStatus) Ready() bool {
+	return !c.Busy && !c.Encoding
+}
+
+// Charging reports whether the battery is charging.
+func (c *cameraStateStatus) Charging() bool {
+	return c.BatteryBars == batteryCharging
+}
+
+// ExternalPower reports whether the camera is powered over USB.
+func (c *cameraStateStatus) ExternalPower() bool {
+	return c.USBConnected || c.Charging()
 }
 
 // MediaList represents the top-level response from the media list API.
@@ -55,6 +87,15 @@ type cameraDateTime struct {
 	TZOffset int    `json:"tzone"` // Timezone offset in minutes
 }
 
+// UTC converts the camera's local date and time to UTC.
+func (dt *cameraDateTime) UTC() (time.Time, error) {
+	local, err := time.Parse("2006_1_2 15_4_5", dt.Date+" "+dt.Time) // tolerates missing zero padding
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid camera date and time '%s %s': %w", dt.Date, dt.Time, err)
+	}
+	return local.Add(-time.Duration(dt.TZOffset) * time.Minute), nil
+}
+
 func (m *MediaListItem) UnmarshalJSON(data []byte) error {
 	type Alias MediaListItem
 	aux := &struct {
@@ -88,8 +129,13 @@ func (m *MediaListItem) UnmarshalJSON(data []byte) error {
 func (c *cameraStateStatus) UnmarshalJSON(data []byte) error {
 	type Alias cameraStateStatus
 	aux := &struct {
-		CapacityKB  int64 `json:"117"`
-		RemainingKB int64 `json:"54"`
+		CapacityKB   int64 `json:"117"`
+		RemainingKB  int64 `json:"54"`
+		Busy         int   `json:"8"`
+		Encoding     int   `json:"10"`
+		SystemHot    int   `json:"6"`
+		TooCold      int   `json:"85"`
+		USBConnected int   `json:"115"`
 		*Alias
 	}{
 		Alias: (*Alias)(c),
@@ -98,6 +144,13 @@ func (c *cameraStateStatus) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
+	// Convert the API's 0/1 flags into booleans.
+	c.Busy = aux.Busy != 0
+	c.Encoding = aux.Encoding != 0
+	c.SystemHot = aux.SystemHot != 0
+	c.TooCold = aux.TooCold != 0
+	c.USBConnected = aux.USBConnected != 0
+
 	// Convert the API's kilobyte values into bytes for storage.
 	const bytesPerKB = 1000
 	c.SDCardCapacity = aux.CapacityKB * bytesPerKB
@@ -0,0 +1,129 @@
+package gopro
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+// Operation is a kind of request, each with its own timeout and retry policy.
+type Operation int
+
+const (
+	OpQuery    Operation = iota // state, info, and settings queries
+	OpDownload                  // media file downloads
+	OpDelete                    // media deletion, which is never retried
+	OpControl                   // commands changing the camera, which are never retried
+)
+
+// String returns the operation's name, as used in the configuration.
+func (op Operation) String() string {
+	switch op {
+	case OpQuery:
+		return "query"
+	case OpDownload:
+		return "download"
+	case OpDelete:
+		return "delete"
+	case OpControl:
+		return "control"
+	default:
+		return fmt.Sprintf("Operation(%d)", int(op))
+	}
+}
+
+// retried reports whether requests of the operation are safe to replay.
+func (op Operation) retried() bool {
+	return op == OpQuery || op == OpDownload
+}
+
+// RequestPolicy controls the timeouts and retries of one kind of request.
+// Zero timeouts mean no limit.
+type RequestPolicy struct {
+	Timeout     time.Duration // limit on each attempt, including reading the body
+	IdleTimeout time.Duration // limit on waiting for more of a streamed body
+	Retries     int
+	BackoffMin  time.Duration // wait before the first retry, doubling after
+	BackoffMax  time.Duration // longest wait between retries
+}
+
+// DefaultPolicies returns the policies used unless overridden with WithPolicy.
+func DefaultPolicies() map[Operation]RequestPolicy {
+	return map[Operation]RequestPolicy{
+		OpQuery:    {Timeout: 15 * time.Second, Retries: 3, BackoffMin: time.Second, BackoffMax: 10 * time.Second},
+		OpDownload: {IdleTimeout: time.Minute, Retries: 4, BackoffMin: time.Second, BackoffMax: 30 * time.Second},
+		OpDelete:   {Timeout: 30 * time.Second},
+		OpControl:  {Timeout: 15 * time.Second},
+	}
+}
+
+// WithPolicy overrides the timeouts and retries for one kind of request.
+// Deletes and control commands are never retried, as a retry after a lost
+// response could fail, hide whether the command took effect, or repeat it.
+func WithPolicy(op Operation, policy RequestPolicy) Option {
+	return func(c *Client) { c.policies[op] = policy }
+}
+
+// configureHTTPClients creates a retrying client per operation, all sharing
+// the same transport and its connections.
+func (c *Client) configureHTTPClients(transport http.RoundTripper) {
+	c.httpClients = make(map[Operation]*retryablehttp.Client, len(c.policies))
+
+	for op, policy := range c.policies {
+		client := retryablehttp.NewClient()
+		client.HTTPClient = &http.Client{Transport: transport, Timeout: policy.Timeout}
+		client.Logger = c.logger
+		client.RetryMax = policy.Retries
+		if !op.retried() {
+			client.RetryMax = 0
+		}
+		if policy.BackoffMin > 0 {
+			client.RetryWaitMin = policy.BackoffMin
+		}
+		if policy.BackoffMax > 0 {
+			client.RetryWaitMax = max(policy.BackoffMax, client.RetryWaitMin)
+		}
+		c.httpClients[op] = client
+	}
+}
+
+// ErrStalled is returned when a streamed download stops receiving data.
+var ErrStalled = errors.New("download stalled")
+
+// idleTimeoutReader cancels a streaming request if no data arrives within the
+// timeout of starting a read. The timer only runs during reads, so time spent
+// between them, e.g., waiting on a rate limiter, doesn't count as a stall.
+type idleTimeoutReader struct {
+	reader  io.Reader
+	timer   *time.Timer
+	timeout time.Duration
+}
+
+// newIdleTimeoutReader wraps the body of a request made with a context whose
+// cancel function is given, canceling it with ErrStalled when reads stall.
+// Stop the returned reader's timer once done reading.
+func newIdleTimeoutReader(body io.Reader, timeout time.Duration, cancel context.CancelCauseFunc) *idleTimeoutReader {
+	return &idleTimeoutReader{
+		reader:  body,
+		timeout: timeout,
+		timer: time.AfterFunc(timeout, func() {
+			cancel(ErrStalled)
+		}),
+	}
+}
+
+func (r *idleTimeoutReader) Read(p []byte) (int, error) {
+	r.timer.Reset(r.timeout)
+	n, err := r.reader.Read(p)
+	r.timer.Stop()
+	return n, err
+}
+
+func (r *idleTimeoutReader) stop() {
+	r.timer.Stop()
+}
@@ -0,0 +1,52 @@
+package gopro
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIdleTimeoutReaderIgnoresTimeBetweenReads(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+
+	r := newIdleTimeoutReader(strings.NewReader("abcdef"), 20*time.Millisecond, cancel)
+	defer r.stop()
+
+	buf := make([]byte, 2)
+	for range 3 {
+		if _, err := r.Read(buf); err != nil {
+			t.Fatal(err)
+		}
+		// E.g., waiting on the rate limiter before the next read.
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	if err := context.Cause(ctx); err != nil {
+		t.Errorf("request canceled between reads: %v", err)
+	}
+}
+
+func TestIdleTimeoutReaderCancelsStalledRead(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+
+	r := newIdleTimeoutReader(blockingReader{ctx}, 20*time.Millisecond, cancel)
+	defer r.stop()
+
+	r.Read(make([]byte, 1))
+	if err := context.Cause(ctx); err != ErrStalled {
+		t.Errorf("stalled read canceled with %v, want %v", err, ErrStalled)
+	}
+}
+
+// blockingReader blocks reads until its context is done.
+type blockingReader struct {
+	ctx context.Context
+}
+
+func (r blockingReader) Read([]byte) (int, error) {
+	<-r.ctx.Done()
+	return 0, r.ctx.Err()
+}
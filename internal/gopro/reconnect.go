@@ -0,0 +1,105 @@
+package gopro
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"time"
+)
+
+// isConnectionError reports whether err means the camera couldn't be reached
+// at all, as opposed to the camera answering with an error. Only failures to
+// connect count: a connection that broke after the request was sent may have
+// reached the camera, and replaying commands such as deletes isn't safe.
+func isConnectionError(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return opErr.Op == "dial"
+	}
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
+
+// reconnect looks for the camera at a new address after a request to failed
+// couldn't connect, e.g., because its DHCP lease changed. The camera is
+// rediscovered the same way it was first found, and only accepted if it has
+// the same serial number. It returns the new base URL.
+func (c *Client) reconnect(ctx context.Context, failed *url.URL) (*url.URL, error) {
+	c.reconnectMu.Lock()
+	defer c.reconnectMu.Unlock()
+
+	// Another request may have found the camera again in the meantime.
+	if current := c.base(); current.Host != failed.Host {
+		return current, nil
+	}
+
+	c.mu.RLock()
+	serial := c.serial
+	c.mu.RUnlock()
+
+	candidates, err := c.candidates(failed.Scheme)
+	if err != nil {
+		return nil, err
+	}
+	if serial == "" && len(candidates) > 1 {
+		return nil, fmt.Errorf("found %d cameras but the original camera's serial number is unknown", len(candidates))
+	}
+
+	for _, candidate := range candidates {
+		if candidate.Host == failed.Host {
+			continue // nothing new to try
+		}
+
+		hwCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		hw, err := c.getHardwareInfo(hwCtx, c.probe, candidate)
+		cancel()
+		if err != nil {
+			c.logger.Debug("skipping unreachable GoPro", slog.String("address", candidate.Host), slog.Any("error", err))
+			continue
+		}
+		if serial != "" && hw.SerialNumber != serial {
+			c.logger.Debug("skipping other GoPro", slog.String("address", candidate.Host), slog.String("serial", hw.SerialNumber))
+			continue
+		}
+
+		c.mu.Lock()
+		c.baseURL = candidate
+		c.serial = hw.SerialNumber
+		c.mu.Unlock()
+
+		c.logger.Info("reconnected to GoPro at new address", slog.String("old", failed.Host), slog.String("new", candidate.Host))
+		return candidate, nil
+	}
+
+	return nil, fmt.Errorf("camera not found at a new address")
+}
+
+// candidates resolves the configured host again, or rediscovers the cameras
+// on the network if no host was configured.
+func (c *Client) candidates(scheme string) ([]*url.URL, error) {
+	if c.host != "" {
+		u, err := resolveGoPro(c.host, scheme)
+		if err != nil {
+			return nil, err
+		}
+		return []*url.URL{u}, nil
+	}
+
+	services, err := Discover(DefaultDiscoveryTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("auto-discovery failed: %w", err)
+	}
+
+	var urls []*url.URL
+	for _, svc := range services {
+		u, err := resolveGoPro(svc.addrFor(scheme), scheme)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, u)
+	}
+	return urls, nil
+}
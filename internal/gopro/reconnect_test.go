@@ -0,0 +1,52 @@
+package gopro
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"syscall"
+	"testing"
+)
+
+func TestIsConnectionError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "connection refused",
+			err:  &url.Error{Op: "Get", URL: "http://10.5.5.9", Err: &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}},
+			want: true,
+		},
+		{
+			name: "unknown host",
+			err:  fmt.Errorf("giving up: %w", &net.DNSError{Err: "no such host", Name: "gopro.local", IsNotFound: true}),
+			want: true,
+		},
+		{
+			name: "connection reset after sending",
+			err:  &url.Error{Op: "Get", URL: "http://10.5.5.9", Err: &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}},
+			want: false,
+		},
+		{
+			name: "write failure",
+			err:  &net.OpError{Op: "write", Net: "tcp", Err: syscall.EPIPE},
+			want: false,
+		},
+		{
+			name: "other error",
+			err:  errors.New("unexpected status code: 500"),
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isConnectionError(tt.err); got != tt.want {
+				t.Errorf("isConnectionError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
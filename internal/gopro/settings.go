@@ -0,0 +1,153 @@
+package gopro
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// SettingID identifies a camera setting.
+//
+// Upstream docs: https://gopro.github.io/OpenGoPro/http#tag/settings
+type SettingID int
+
+const (
+	SettingResolution    SettingID = 2
+	SettingFPS           SettingID = 3
+	SettingAutoPowerDown SettingID = 59
+	SettingLens          SettingID = 121
+	SettingHyperSmooth   SettingID = 135
+	SettingBitRate       SettingID = 182
+)
+
+// SettingOption is a named value of a setting.
+type SettingOption struct {
+	Value int
+	Name  string
+}
+
+// Setting describes a camera setting and its known options.
+type Setting struct {
+	ID      SettingID
+	Key     string // name used in the config file and on the command line
+	Label   string
+	Options []SettingOption
+}
+
+// KnownSettings lists the settings herosync understands, in display order.
+// Not every option is available on every model.
+var KnownSettings = []Setting{
+	{SettingResolution, "resolution", "Video Resolution", []SettingOption{
+		{1, "4K"}, {4, "2.7K"}, {6, "2.7K 4:3"}, {7, "1440"}, {9, "1080"},
+		{18, "4K 4:3"}, {24, "5K"}, {25, "5K 4:3"}, {26, "5.3K 8:7"},
+		{27, "5.3K 4:3"}, {28, "4K 8:7"}, {100, "5.3K"}, {107, "5.3K 8:7 V2"},
+		{108, "4K 8:7 V2"}, {109, "4K 9:16"}, {110, "1080 9:16"},
+		{111, "2.7K 4:3 V2"}, {112, "4K 4:3 V2"}, {113, "5.3K 4:3 V2"},
+	}},
+	{SettingFPS, "fps", "Frames Per Second", []SettingOption{
+		{0, "240"}, {1, "120"}, {2, "100"}, {5, "60"}, {6, "50"}, {8, "30"},
+		{9, "25"}, {10, "24"}, {13, "200"}, {15, "400"}, {16, "360"}, {17, "300"},
+	}},
+	{SettingLens, "lens", "Video Lens", []SettingOption{
+		{0, "Wide"}, {2, "Narrow"}, {3, "SuperView"}, {4, "Linear"},
+		{7, "Max SuperView"}, {8, "Linear + Horizon Leveling"}, {9, "HyperView"},
+		{10, "Linear + Horizon Lock"}, {11, "Max HyperView"},
+		{12, "Ultra SuperView"}, {13, "Ultra Wide"}, {14, "Ultra Linear"},
+		{104, "Ultra HyperView"},
+	}},
+	{SettingHyperSmooth, "hypersmooth", "HyperSmooth", []SettingOption{
+		{0, "Off"}, {1, "Low"}, {2, "High"}, {3, "Boost"}, {4, "Auto Boost"},
+		{100, "Standard"},
+	}},
+	{SettingAutoPowerDown, "auto-power-down", "Auto Power Down", []SettingOption{
+		{0, "Never"}, {1, "1 Min"}, {4, "5 Min"}, {6, "15 Min"}, {7, "30 Min"},
+		{11, "8 Seconds"}, {12, "30 Seconds"},
+	}},
+	{SettingBitRate, "bit-rate", "Video Bit Rate", []SettingOption{
+		{0, "Standard"}, {1, "High"},
+	}},
+}
+
+// LookupSetting returns the known setting with the given key.
+func LookupSetting(key string) (Setting, error) {
+	i := slices.IndexFunc(KnownSettings, func(s Setting) bool { return s.Key == strings.ToLower(key) })
+	if i < 0 {
+		keys := make([]string, len(KnownSettings))
+		for j, s := range KnownSettings {
+			keys[j] = s.Key
+		}
+		return Setting{}, fmt.Errorf("unknown setting: %q (choose %s)", key, strings.Join(keys, ", "))
+	}
+	return KnownSettings[i], nil
+}
+
+// SettingValue is a setting paired with one of its option values.
+type SettingValue struct {
+	Setting
+	Option int
+}
+
+// ParseSettingsProfile converts a settings profile, mapping setting keys to
+// option names as written in the config file, into setting values in display
+// order.
+func ParseSettingsProfile(profile map[string]string) ([]SettingValue, error) {
+	keys := slices.Sorted(maps.Keys(profile))
+	for _, key := range keys {
+		if _, err := LookupSetting(key); err != nil {
+			return nil, err
+		}
+	}
+
+	var values []SettingValue
+	for _, setting := range KnownSettings {
+		for _, key := range keys {
+			if !strings.EqualFold(key, setting.Key) {
+				continue
+			}
+			option, err := setting.ParseOption(profile[key])
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, SettingValue{setting, option})
+		}
+	}
+	return values, nil
+}
+
+// OptionName returns the name of the option value, or the number itself if
+// the option isn't known.
+func (s Setting) OptionName(value int) string {
+	for _, opt := range s.Options {
+		if opt.Value == value {
+			return opt.Name
+		}
+	}
+	return strconv.Itoa(value)
+}
+
+// ParseOption converts an option name, matched case-insensitively, to its
+// value. A "#" prefix passes a raw option value through, for options not
+// known to herosync (e.g., "#3").
+func (s Setting) ParseOption(name string) (int, error) {
+	if raw, ok := strings.CutPrefix(name, "#"); ok {
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s option value: %q", s.Key, name)
+		}
+		return value, nil
+	}
+
+	for _, opt := range s.Options {
+		if strings.EqualFold(opt.Name, strings.TrimSpace(name)) {
+			return opt.Value, nil
+		}
+	}
+
+	names := make([]string, len(s.Options))
+	for i, opt := range s.Options {
+		names[i] = fmt.Sprintf("%q", opt.Name)
+	}
+	return 0, fmt.Errorf("invalid %s: %q (choose %s)", s.Key, name, strings.Join(names, ", "))
+}
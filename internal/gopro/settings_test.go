@@ -0,0 +1,88 @@
+package gopro
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseOption(t *testing.T) {
+	resolution, err := LookupSetting("resolution")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		want    int
+		wantErr bool
+	}{
+		{name: "exact name", input: "4K", want: 1},
+		{name: "case-insensitive", input: "5.3k 8:7", want: 26},
+		{name: "surrounding space", input: " 1080 ", want: 9},
+		{name: "raw value", input: "#42", want: 42},
+		{name: "invalid raw value", input: "#4K", wantErr: true},
+		{name: "unknown name", input: "8K", wantErr: true},
+		{name: "empty", input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolution.ParseOption(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseOption(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseOption(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSettingsProfile(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile map[string]string
+		want    []SettingValue
+		wantErr bool
+	}{
+		{
+			name:    "display order",
+			profile: map[string]string{"lens": "Linear", "FPS": "60", "resolution": "4K"},
+			want: []SettingValue{
+				{Setting: KnownSettings[0], Option: 1},
+				{Setting: KnownSettings[1], Option: 5},
+				{Setting: KnownSettings[2], Option: 4},
+			},
+		},
+		{
+			name:    "empty",
+			profile: nil,
+			want:    nil,
+		},
+		{
+			name:    "unknown setting",
+			profile: map[string]string{"resolution": "4K", "zoom": "2x"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown option",
+			profile: map[string]string{"fps": "59.94"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSettingsProfile(tt.profile)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSettingsProfile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			equal := func(a, b SettingValue) bool { return a.ID == b.ID && a.Option == b.Option }
+			if !slices.EqualFunc(got, tt.want, equal) {
+				t.Errorf("ParseSettingsProfile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package gpmf
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// WriteGPX writes the GPS samples with a usable fix as a GPX 1.1 track.
+func WriteGPX(w io.Writer, t *Telemetry, name string) error {
+	type trkpt struct {
+		Lat  float64 `xml:"lat,attr"`
+		Lon  float64 `xml:"lon,attr"`
+		Ele  float64 `xml:"ele"`
+		Time string  `xml:"time"`
+	}
+	type gpx struct {
+		XMLName xml.Name `xml:"gpx"`
+		Version string   `xml:"version,attr"`
+		Creator string   `xml:"creator,attr"`
+		Xmlns   string   `xml:"xmlns,attr"`
+		Name    string   `xml:"trk>name"`
+		Points  []trkpt  `xml:"trk>trkseg>trkpt"`
+	}
+
+	doc := gpx{
+		Version: "1.1",
+		Creator: "herosync",
+		Xmlns:   "http://www.topografix.com/GPX/1/1",
+		Name:    name,
+	}
+	for _, s := range t.GPS {
+		if !s.HasFix() {
+			continue
+		}
+		doc.Points = append(doc.Points, trkpt{
+			Lat:  s.Latitude,
+			Lon:  s.Longitude,
+			Ele:  s.Altitude,
+			Time: formatTime(s.Time),
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("encoding GPX: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// WriteGeoJSON writes the GPS samples with a usable fix as a GeoJSON feature
+// collection holding a single LineString, with per-point timestamps and speeds
+// stored in the feature properties.
+func WriteGeoJSON(w io.Writer, t *Telemetry, name string) error {
+	var coordinates [][3]float64
+	var times []string
+	var speeds []float64
+
+	for _, s := range t.GPS {
+		if !s.HasFix() {
+			continue
+		}
+		coordinates = append(coordinates, [3]float64{s.Longitude, s.Latitude, s.Altitude})
+		times = append(times, formatTime(s.Time))
+		speeds = append(speeds, s.Speed2D)
+	}
+
+	doc := map[string]any{
+		"type": "FeatureCollection",
+		"features": []any{
+			map[string]any{
+				"type": "Feature",
+				"geometry": map[string]any{
+					"type":        "LineString",
+					"coordinates": coordinates,
+				},
+				"properties": map[string]any{
+					"name":       name,
+					"coordTimes": times,
+					"speeds":     speeds,
+				},
+			},
+		},
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("encoding GeoJSON: %w", err)
+	}
+	return nil
+}
+
+// WriteGPSCSV writes all GPS samples as CSV.
+func WriteGPSCSV(w io.Writer, t *Telemetry) error {
+	records := [][]string{{"time", "latitude", "longitude", "altitude_m", "speed_2d_mps", "speed_3d_mps", "fix", "dop"}}
+	for _, s := range t.GPS {
+		records = append(records, []string{
+			formatTime(s.Time),
+			formatFloat(s.Latitude),
+			formatFloat(s.Longitude),
+			formatFloat(s.Altitude),
+			formatFloat(s.Speed2D),
+			formatFloat(s.Speed3D),
+			strconv.Itoa(s.Fix),
+			formatFloat(s.DOP),
+		})
+	}
+	return writeCSV(w, records)
+}
+
+// WriteVectorCSV writes three-axis sensor samples as CSV.
+func WriteVectorCSV(w io.Writer, samples []Vector3Sample) error {
+	records := [][]string{{"time", "x", "y", "z"}}
+	for _, s := range samples {
+		records = append(records, []string{
+			formatTime(s.Time),
+			formatFloat(s.X),
+			formatFloat(s.Y),
+			formatFloat(s.Z),
+		})
+	}
+	return writeCSV(w, records)
+}
+
+// WriteTemperatureCSV writes temperature samples as CSV.
+func WriteTemperatureCSV(w io.Writer, samples []TemperatureSample) error {
+	records := [][]string{{"time", "celsius"}}
+	for _, s := range samples {
+		records = append(records, []string{formatTime(s.Time), formatFloat(s.Celsius)})
+	}
+	return writeCSV(w, records)
+}
+
+func writeCSV(w io.Writer, records [][]string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.WriteAll(records); err != nil {
+		return fmt.Errorf("writing CSV: %w", err)
+	}
+	return nil
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000Z07:00")
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
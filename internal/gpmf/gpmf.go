@@ -0,0 +1,223 @@
+// Package gpmf extracts GoPro Metadata Format (GPMF) telemetry from the
+// "gpmd" track of GoPro MP4 files.
+//
+// Upstream docs: https://github.com/gopro/gpmf-parser
+package gpmf
+
+import (
+	"time"
+)
+
+// GPSSample is a single GPS position.
+type GPSSample struct {
+	Time      time.Time
+	Latitude  float64 // degrees
+	Longitude float64 // degrees
+	Altitude  float64 // meters above the WGS 84 ellipsoid
+	Speed2D   float64 // meters per second
+	Speed3D   float64 // meters per second
+	Fix       int     // 0 = no lock, 2 = 2D lock, 3 = 3D lock, -1 = not reported
+	DOP       float64 // dilution of precision
+}
+
+// HasFix reports whether the sample represents a usable position.
+func (s GPSSample) HasFix() bool {
+	if s.Latitude == 0 && s.Longitude == 0 {
+		return false
+	}
+	return s.Fix >= 2 || s.Fix < 0
+}
+
+// Vector3Sample is a single three-axis sensor reading, with the axes in the
+// order recorded by the camera.
+type Vector3Sample struct {
+	Time    time.Time
+	X, Y, Z float64
+}
+
+// TemperatureSample is a single camera temperature reading.
+type TemperatureSample struct {
+	Time    time.Time
+	Celsius float64
+}
+
+// Telemetry holds the samples extracted from a file.
+type Telemetry struct {
+	GPS           []GPSSample
+	Accelerometer []Vector3Sample // meters per second squared
+	Gyroscope     []Vector3Sample // radians per second
+	Temperature   []TemperatureSample
+}
+
+// Extract reads all telemetry from the MP4 file at path. Sample timestamps are
+// aligned to start, which should be the creation time of the file.
+func Extract(path string, start time.Time) (*Telemetry, error) {
+	payloads, err := readPayloads(path)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Telemetry{}
+	for _, p := range payloads {
+		// Keep whatever could be parsed from a damaged payload.
+		entries, _ := parseKLV(p.Data)
+
+		payloadStart := start.Add(seconds(p.Time))
+		for _, devc := range entries {
+			if devc.Key != "DEVC" {
+				continue
+			}
+			for _, strm := range devc.Children {
+				if strm.Key == "STRM" {
+					t.addStream(strm.Children, payloadStart, p.Duration)
+				}
+			}
+		}
+	}
+
+	return t, nil
+}
+
+// FirstFix returns the first GPS sample with a usable position.
+func (t *Telemetry) FirstFix() (GPSSample, bool) {
+	for _, s := range t.GPS {
+		if s.HasFix() {
+			return s, true
+		}
+	}
+	return GPSSample{}, false
+}
+
+// IsEmpty reports whether no samples were extracted.
+func (t *Telemetry) IsEmpty() bool {
+	return len(t.GPS) == 0 && len(t.Accelerometer) == 0 && len(t.Gyroscope) == 0 && len(t.Temperature) == 0
+}
+
+// addStream decodes the samples of a single GPMF stream. Metadata entries such
+// as scale factors are "sticky" and apply to the data entries that follow.
+func (t *Telemetry) addStream(entries []klv, start time.Time, duration float64) {
+	var scale []float64
+	var complexType string
+	fix := -1
+	var dop float64
+
+	for _, e := range entries {
+		switch e.Key {
+		case "SCAL":
+			scale = e.values()
+		case "TYPE":
+			complexType = e.String()
+		case "GPSF":
+			if v := e.values(); len(v) > 0 {
+				fix = int(v[0])
+			}
+		case "GPSP":
+			if v := e.values(); len(v) > 0 {
+				dop = v[0] / 100
+			}
+		case "TMPC":
+			if v := e.values(); len(v) > 0 {
+				t.addTemperature(start, v[0])
+			}
+		case "GPS5":
+			samples := applyScale(e.samples(complexType), scale)
+			for i, s := range samples {
+				if len(s) < 5 {
+					continue
+				}
+				t.GPS = append(t.GPS, GPSSample{
+					Time:      sampleTime(start, duration, i, len(samples)),
+					Latitude:  s[0],
+					Longitude: s[1],
+					Altitude:  s[2],
+					Speed2D:   s[3],
+					Speed3D:   s[4],
+					Fix:       fix,
+					DOP:       dop,
+				})
+			}
+		case "GPS9":
+			samples := applyScale(e.samples(complexType), scale)
+			for i, s := range samples {
+				if len(s) < 9 {
+					continue
+				}
+				t.GPS = append(t.GPS, GPSSample{
+					Time:      sampleTime(start, duration, i, len(samples)),
+					Latitude:  s[0],
+					Longitude: s[1],
+					Altitude:  s[2],
+					Speed2D:   s[3],
+					Speed3D:   s[4],
+					DOP:       s[7],
+					Fix:       int(s[8]),
+				})
+			}
+		case "ACCL":
+			t.Accelerometer = append(t.Accelerometer, vectorSamples(e, complexType, scale, start, duration)...)
+		case "GYRO":
+			t.Gyroscope = append(t.Gyroscope, vectorSamples(e, complexType, scale, start, duration)...)
+		}
+	}
+}
+
+// addTemperature records a temperature reading, ignoring duplicates reported
+// by multiple streams within the same payload.
+func (t *Telemetry) addTemperature(at time.Time, celsius float64) {
+	if n := len(t.Temperature); n > 0 && t.Temperature[n-1].Time.Equal(at) {
+		return
+	}
+	t.Temperature = append(t.Temperature, TemperatureSample{Time: at, Celsius: celsius})
+}
+
+// vectorSamples decodes a three-axis sensor entry.
+func vectorSamples(e klv, complexType string, scale []float64, start time.Time, duration float64) []Vector3Sample {
+	samples := applyScale(e.samples(complexType), scale)
+
+	result := make([]Vector3Sample, 0, len(samples))
+	for i, s := range samples {
+		if len(s) < 3 {
+			continue
+		}
+		result = append(result, Vector3Sample{
+			Time: sampleTime(start, duration, i, len(samples)),
+			X:    s[0],
+			Y:    s[1],
+			Z:    s[2],
+		})
+	}
+	return result
+}
+
+// applyScale divides raw values by their scale factors, which are given either
+// once for all elements or once per element.
+func applyScale(samples [][]float64, scale []float64) [][]float64 {
+	if len(scale) == 0 {
+		return samples
+	}
+	for _, s := range samples {
+		for i := range s {
+			divisor := scale[0]
+			if len(scale) > 1 && i < len(scale) {
+				divisor = scale[i]
+			}
+			if divisor != 0 {
+				s[i] /= divisor
+			}
+		}
+	}
+	return samples
+}
+
+// sampleTime spreads the samples of a payload evenly across its duration.
+func sampleTime(start time.Time, duration float64, index, count int) time.Time {
+	if count == 0 {
+		return start
+	}
+	return start.Add(seconds(duration * float64(index) / float64(count)))
+}
+
+// seconds converts fractional seconds to a time.Duration.
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
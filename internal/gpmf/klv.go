@@ -0,0 +1,162 @@
+package gpmf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// klv is a single GPMF key-length-value entry.
+//
+// Upstream docs: https://github.com/gopro/gpmf-parser#gpmf-deeper-dive
+type klv struct {
+	Key      string
+	Type     byte // Value type character, or 0 for nested entries
+	Size     int  // Size of a single sample in bytes
+	Repeat   int  // Number of samples
+	Data     []byte
+	Children []klv
+}
+
+// parseKLV decodes a buffer of GPMF entries, descending into nested entries.
+func parseKLV(data []byte) ([]klv, error) {
+	var entries []klv
+	for len(data) >= 8 {
+		entry := klv{
+			Key:    string(data[0:4]),
+			Type:   data[4],
+			Size:   int(data[5]),
+			Repeat: int(binary.BigEndian.Uint16(data[6:8])),
+		}
+
+		// A zeroed key marks padding at the end of a payload.
+		if data[0] == 0 {
+			break
+		}
+
+		length := entry.Size * entry.Repeat
+		padded := (length + 3) &^ 3 // values are aligned to 32 bits
+		if 8+length > len(data) {
+			return entries, fmt.Errorf("truncated %s entry", entry.Key)
+		}
+		entry.Data = data[8 : 8+length]
+
+		if entry.Type == 0 {
+			children, err := parseKLV(entry.Data)
+			if err != nil {
+				return entries, err
+			}
+			entry.Children = children
+		}
+
+		entries = append(entries, entry)
+
+		if 8+padded > len(data) {
+			break
+		}
+		data = data[8+padded:]
+	}
+	return entries, nil
+}
+
+// typeSize returns the size in bytes of a single GPMF value type.
+func typeSize(t byte) int {
+	switch t {
+	case 'b', 'B', 'c':
+		return 1
+	case 's', 'S':
+		return 2
+	case 'f', 'l', 'L', 'q', 'F':
+		return 4
+	case 'd', 'j', 'J', 'Q':
+		return 8
+	default:
+		return 0
+	}
+}
+
+// decodeValue converts a single raw value of the given type to a float64.
+func decodeValue(t byte, b []byte) float64 {
+	switch t {
+	case 'b':
+		return float64(int8(b[0]))
+	case 'B':
+		return float64(b[0])
+	case 's':
+		return float64(int16(binary.BigEndian.Uint16(b)))
+	case 'S':
+		return float64(binary.BigEndian.Uint16(b))
+	case 'l':
+		return float64(int32(binary.BigEndian.Uint32(b)))
+	case 'L':
+		return float64(binary.BigEndian.Uint32(b))
+	case 'f':
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b)))
+	case 'd':
+		return math.Float64frombits(binary.BigEndian.Uint64(b))
+	case 'j':
+		return float64(int64(binary.BigEndian.Uint64(b)))
+	case 'J':
+		return float64(binary.BigEndian.Uint64(b))
+	case 'q': // Q15.16 fixed point
+		return float64(int32(binary.BigEndian.Uint32(b))) / (1 << 16)
+	case 'Q': // Q31.32 fixed point
+		return float64(int64(binary.BigEndian.Uint64(b))) / (1 << 32)
+	default:
+		return 0
+	}
+}
+
+// samples decodes the entry into one slice of values per sample. Complex
+// entries (type '?') are decoded using the element types from a TYPE entry.
+func (k klv) samples(complexType string) [][]float64 {
+	types := make([]byte, 0, 16)
+	if k.Type == '?' {
+		types = append(types, complexType...)
+	} else {
+		size := typeSize(k.Type)
+		if size == 0 {
+			return nil
+		}
+		for range k.Size / size {
+			types = append(types, k.Type)
+		}
+	}
+
+	result := make([][]float64, 0, k.Repeat)
+	for i := range k.Repeat {
+		sample := k.Data[i*k.Size : (i+1)*k.Size]
+
+		values := make([]float64, 0, len(types))
+		pos := 0
+		for _, t := range types {
+			size := typeSize(t)
+			if size == 0 || pos+size > len(sample) {
+				break
+			}
+			values = append(values, decodeValue(t, sample[pos:pos+size]))
+			pos += size
+		}
+		result = append(result, values)
+	}
+	return result
+}
+
+// values decodes the entry as a flat list of values, as used for metadata
+// like scale factors.
+func (k klv) values() []float64 {
+	var flat []float64
+	for _, sample := range k.samples("") {
+		flat = append(flat, sample...)
+	}
+	return flat
+}
+
+// String decodes the entry as a character string.
+func (k klv) String() string {
+	end := len(k.Data)
+	for end > 0 && k.Data[end-1] == 0 {
+		end--
+	}
+	return string(k.Data[:end])
+}
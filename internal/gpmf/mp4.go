@@ -0,0 +1,410 @@
+package gpmf
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+)
+
+// ErrNoTelemetry is returned when a file doesn't contain a GPMF metadata track.
+var ErrNoTelemetry = errors.New("no GPMF telemetry track found")
+
+// Limits guarding against corrupt files claiming huge sizes. GoPro files have
+// a moov box of a few megabytes, and a telemetry sample of a few kilobytes
+// for each second of video.
+const (
+	maxMoovSize   = 64 << 20
+	maxSamples    = 1 << 20
+	maxSampleSize = 16 << 20
+)
+
+// payload is a single GPMF sample read from the MP4 metadata track.
+type payload struct {
+	Time     float64 // Offset from the start of the file in seconds
+	Duration float64 // Duration covered by the payload in seconds
+	Data     []byte
+}
+
+// box is an MP4 box header along with the location of its contents.
+type box struct {
+	Type string
+	Body []byte
+}
+
+// sampleTable holds the parts of an MP4 sample table needed to locate samples.
+type sampleTable struct {
+	timescale    uint32
+	sizes        []uint32
+	chunkOffsets []uint64
+	chunkRuns    []chunkRun
+	timeToSample []timeToSample
+}
+
+type chunkRun struct {
+	firstChunk      uint32
+	samplesPerChunk uint32
+}
+
+type timeToSample struct {
+	count uint32
+	delta uint32
+}
+
+// readPayloads reads every GPMF payload from the "gpmd" track of an MP4 file.
+func readPayloads(path string) ([]payload, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	fileSize := info.Size()
+
+	moov, err := readMoov(f, fileSize)
+	if err != nil {
+		return nil, err
+	}
+
+	table, err := findGPMDTrack(moov, fileSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return table.readSamples(f, fileSize)
+}
+
+// readMoov scans the top-level boxes of the file and returns the "moov" box contents.
+func readMoov(r io.ReaderAt, fileSize int64) ([]byte, error) {
+	var offset int64
+	header := make([]byte, 16)
+	for offset < fileSize {
+		if _, err := r.ReadAt(header[:8], offset); err != nil {
+			return nil, fmt.Errorf("reading box header: %w", err)
+		}
+
+		size := int64(binary.BigEndian.Uint32(header[0:4]))
+		typ := string(header[4:8])
+		headerSize := int64(8)
+
+		switch size {
+		case 0: // box extends to the end of the file
+			size = fileSize - offset
+		case 1: // 64-bit extended size follows the type
+			if _, err := r.ReadAt(header[8:16], offset+8); err != nil {
+				return nil, fmt.Errorf("reading box header: %w", err)
+			}
+			size = int64(binary.BigEndian.Uint64(header[8:16]))
+			headerSize = 16
+		}
+
+		if size < headerSize || offset+size > fileSize {
+			return nil, fmt.Errorf("invalid %q box size %d at offset %d", typ, size, offset)
+		}
+
+		if typ == "moov" {
+			if size-headerSize > maxMoovSize {
+				return nil, fmt.Errorf("moov box too large: %d bytes", size-headerSize)
+			}
+			body := make([]byte, size-headerSize)
+			if _, err := r.ReadAt(body, offset+headerSize); err != nil && !errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("reading moov box: %w", err)
+			}
+			return body, nil
+		}
+
+		offset += size
+	}
+
+	return nil, fmt.Errorf("no moov box found")
+}
+
+// parseBoxes splits data into its child boxes.
+func parseBoxes(data []byte) ([]box, error) {
+	var boxes []box
+	for len(data) > 0 {
+		if len(data) < 8 {
+			return nil, fmt.Errorf("truncated box header")
+		}
+
+		size := uint64(binary.BigEndian.Uint32(data[0:4]))
+		typ := string(data[4:8])
+		headerSize := uint64(8)
+
+		switch size {
+		case 0:
+			size = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return nil, fmt.Errorf("truncated box header")
+			}
+			size = binary.BigEndian.Uint64(data[8:16])
+			headerSize = 16
+		}
+
+		if size < headerSize || size > uint64(len(data)) {
+			return nil, fmt.Errorf("invalid %q box size %d", typ, size)
+		}
+
+		boxes = append(boxes, box{Type: typ, Body: data[headerSize:size]})
+		data = data[size:]
+	}
+	return boxes, nil
+}
+
+// findBox follows a path of box types and returns the first matching box body.
+func findBox(data []byte, path ...string) ([]byte, bool) {
+	for _, typ := range path {
+		boxes, err := parseBoxes(data)
+		if err != nil {
+			return nil, false
+		}
+
+		found := false
+		for _, b := range boxes {
+			if b.Type == typ {
+				data = b.Body
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, false
+		}
+	}
+	return data, true
+}
+
+// findGPMDTrack locates the track whose sample description is "gpmd" in a
+// file of the given size.
+func findGPMDTrack(moov []byte, fileSize int64) (*sampleTable, error) {
+	boxes, err := parseBoxes(moov)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, b := range boxes {
+		if b.Type != "trak" {
+			continue
+		}
+
+		stsd, ok := findBox(b.Body, "mdia", "minf", "stbl", "stsd")
+		if !ok || sampleFormat(stsd) != "gpmd" {
+			continue
+		}
+
+		return parseSampleTable(b.Body, fileSize)
+	}
+
+	return nil, ErrNoTelemetry
+}
+
+// sampleFormat returns the format of the first entry in a sample description box.
+func sampleFormat(stsd []byte) string {
+	// version/flags (4), entry count (4), then the first entry: size (4), format (4)
+	if len(stsd) < 16 {
+		return ""
+	}
+	return string(stsd[12:16])
+}
+
+// parseSampleTable decodes the sample table of a track in a file of the given
+// size. Tables describing more sample data than the file holds are rejected.
+func parseSampleTable(trak []byte, fileSize int64) (*sampleTable, error) {
+	mdhd, ok := findBox(trak, "mdia", "mdhd")
+	if !ok || len(mdhd) < 24 {
+		return nil, fmt.Errorf("missing media header")
+	}
+
+	table := &sampleTable{}
+	if mdhd[0] == 1 { // version 1 uses 64-bit creation and modification times
+		if len(mdhd) < 32 {
+			return nil, fmt.Errorf("truncated media header")
+		}
+		table.timescale = binary.BigEndian.Uint32(mdhd[20:24])
+	} else {
+		table.timescale = binary.BigEndian.Uint32(mdhd[12:16])
+	}
+	if table.timescale == 0 {
+		return nil, fmt.Errorf("invalid media timescale")
+	}
+
+	stbl, _ := findBox(trak, "mdia", "minf", "stbl")
+
+	if stsz, ok := findBox(stbl, "stsz"); ok && len(stsz) >= 12 {
+		sampleSize := binary.BigEndian.Uint32(stsz[4:8])
+		count := int(binary.BigEndian.Uint32(stsz[8:12]))
+		if count > maxSamples {
+			return nil, fmt.Errorf("too many telemetry samples: %d", count)
+		}
+		if sampleSize != 0 {
+			if uint64(count)*uint64(sampleSize) > uint64(fileSize) {
+				return nil, fmt.Errorf("sample size table exceeds file size")
+			}
+			table.sizes = slices.Repeat([]uint32{sampleSize}, count)
+		} else {
+			if len(stsz) < 12+4*count {
+				return nil, fmt.Errorf("truncated sample size table")
+			}
+			var total uint64
+			for i := range count {
+				pos := 12 + 4*i
+				size := binary.BigEndian.Uint32(stsz[pos : pos+4])
+				total += uint64(size)
+				table.sizes = append(table.sizes, size)
+			}
+			if total > uint64(fileSize) {
+				return nil, fmt.Errorf("sample size table exceeds file size")
+			}
+		}
+	}
+
+	if stco, ok := findBox(stbl, "stco"); ok && len(stco) >= 8 {
+		count := int(binary.BigEndian.Uint32(stco[4:8]))
+		if len(stco) < 8+4*count {
+			return nil, fmt.Errorf("truncated chunk offset table")
+		}
+		for i := range count {
+			pos := 8 + 4*i
+			table.chunkOffsets = append(table.chunkOffsets, uint64(binary.BigEndian.Uint32(stco[pos:pos+4])))
+		}
+	} else if co64, ok := findBox(stbl, "co64"); ok && len(co64) >= 8 {
+		count := int(binary.BigEndian.Uint32(co64[4:8]))
+		if len(co64) < 8+8*count {
+			return nil, fmt.Errorf("truncated chunk offset table")
+		}
+		for i := range count {
+			pos := 8 + 8*i
+			table.chunkOffsets = append(table.chunkOffsets, binary.BigEndian.Uint64(co64[pos:pos+8]))
+		}
+	}
+
+	if stsc, ok := findBox(stbl, "stsc"); ok && len(stsc) >= 8 {
+		count := int(binary.BigEndian.Uint32(stsc[4:8]))
+		if len(stsc) < 8+12*count {
+			return nil, fmt.Errorf("truncated sample-to-chunk table")
+		}
+		for i := range count {
+			pos := 8 + 12*i
+			table.chunkRuns = append(table.chunkRuns, chunkRun{
+				firstChunk:      binary.BigEndian.Uint32(stsc[pos : pos+4]),
+				samplesPerChunk: binary.BigEndian.Uint32(stsc[pos+4 : pos+8]),
+			})
+		}
+	}
+
+	if stts, ok := findBox(stbl, "stts"); ok && len(stts) >= 8 {
+		count := int(binary.BigEndian.Uint32(stts[4:8]))
+		if len(stts) < 8+8*count {
+			return nil, fmt.Errorf("truncated time-to-sample table")
+		}
+		for i := range count {
+			pos := 8 + 8*i
+			table.timeToSample = append(table.timeToSample, timeToSample{
+				count: binary.BigEndian.Uint32(stts[pos : pos+4]),
+				delta: binary.BigEndian.Uint32(stts[pos+4 : pos+8]),
+			})
+		}
+	}
+
+	if len(table.sizes) == 0 || len(table.chunkOffsets) == 0 || len(table.chunkRuns) == 0 {
+		return nil, fmt.Errorf("incomplete sample table for telemetry track")
+	}
+
+	return table, nil
+}
+
+// readSamples reads each sample of the track from a file of the given size,
+// along with its timing.
+func (t *sampleTable) readSamples(r io.ReaderAt, fileSize int64) ([]payload, error) {
+	offsets := t.sampleOffsets()
+	times, durations := t.sampleTimes()
+
+	payloads := make([]payload, 0, len(offsets))
+	for i, offset := range offsets {
+		size := uint64(t.sizes[i])
+		if size > maxSampleSize || offset > uint64(fileSize) || size > uint64(fileSize)-offset {
+			return nil, fmt.Errorf("telemetry sample %d of %d bytes at offset %d lies outside the file", i, size, offset)
+		}
+
+		data := make([]byte, size)
+		if _, err := r.ReadAt(data, int64(offset)); err != nil {
+			return nil, fmt.Errorf("reading telemetry sample %d: %w", i, err)
+		}
+
+		payloads = append(payloads, payload{
+			Time:     times[i],
+			Duration: durations[i],
+			Data:     data,
+		})
+	}
+
+	return payloads, nil
+}
+
+// sampleOffsets computes the file offset of each sample from the chunk tables.
+func (t *sampleTable) sampleOffsets() []uint64 {
+	var offsets []uint64
+	sample := 0
+
+	for chunk := range t.chunkOffsets {
+		chunkNumber := uint32(chunk + 1) // chunks are numbered from 1
+
+		// Find the run that covers this chunk.
+		var samplesPerChunk uint32
+		for _, run := range t.chunkRuns {
+			if run.firstChunk > chunkNumber {
+				break
+			}
+			samplesPerChunk = run.samplesPerChunk
+		}
+
+		offset := t.chunkOffsets[chunk]
+		for range samplesPerChunk {
+			if sample >= len(t.sizes) {
+				return offsets
+			}
+			offsets = append(offsets, offset)
+			offset += uint64(t.sizes[sample])
+			sample++
+		}
+	}
+
+	return offsets
+}
+
+// sampleTimes computes the start time and duration of each sample in seconds.
+func (t *sampleTable) sampleTimes() (times, durations []float64) {
+	scale := float64(t.timescale)
+	var elapsed uint64
+
+	for _, entry := range t.timeToSample {
+		for range entry.count {
+			if len(times) == len(t.sizes) {
+				return times, durations // ignore timing for samples that don't exist
+			}
+			times = append(times, float64(elapsed)/scale)
+			durations = append(durations, float64(entry.delta)/scale)
+			elapsed += uint64(entry.delta)
+		}
+	}
+
+	// Pad missing timing entries using the last known duration.
+	for len(times) < len(t.sizes) {
+		last := 1.0
+		if len(durations) > 0 {
+			last = durations[len(durations)-1]
+		}
+		times = append(times, float64(elapsed)/scale)
+		durations = append(durations, last)
+		elapsed += uint64(last * scale)
+	}
+
+	return times, durations
+}
@@ -0,0 +1,283 @@
+package gpmf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// mp4Box encodes an MP4 box from its type and contents.
+func mp4Box(typ string, parts ...[]byte) []byte {
+	body := bytes.Join(parts, nil)
+	b := binary.BigEndian.AppendUint32(nil, uint32(8+len(body)))
+	return append(append(b, typ...), body...)
+}
+
+// u32s encodes big-endian 32-bit values.
+func u32s(values ...uint32) []byte {
+	var b []byte
+	for _, v := range values {
+		b = binary.BigEndian.AppendUint32(b, v)
+	}
+	return b
+}
+
+// gpmfEntry encodes a GPMF KLV entry, padding its value to 32 bits.
+func gpmfEntry(key string, typ byte, size, repeat int, data []byte) []byte {
+	b := append([]byte(key), typ, byte(size))
+	b = binary.BigEndian.AppendUint16(b, uint16(repeat))
+	b = append(b, data...)
+	for len(b)%4 != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+// gpmfNested encodes a GPMF entry holding other entries.
+func gpmfNested(key string, children ...[]byte) []byte {
+	data := bytes.Join(children, nil)
+	return gpmfEntry(key, 0, 1, len(data), data)
+}
+
+// i16s encodes big-endian signed 16-bit values.
+func i16s(values ...int16) []byte {
+	var b []byte
+	for _, v := range values {
+		b = binary.BigEndian.AppendUint16(b, uint16(v))
+	}
+	return b
+}
+
+// i32s encodes big-endian signed 32-bit values.
+func i32s(values ...int32) []byte {
+	var b []byte
+	for _, v := range values {
+		b = binary.BigEndian.AppendUint32(b, uint32(v))
+	}
+	return b
+}
+
+// testPayload is a GPMF payload holding two accelerometer samples and a GPS fix.
+func testPayload() []byte {
+	accl := gpmfNested("STRM",
+		gpmfEntry("SCAL", 's', 2, 1, i16s(10)),
+		gpmfEntry("ACCL", 's', 6, 2, i16s(10, 20, 30, -10, -20, -30)),
+	)
+	gps := gpmfNested("STRM",
+		gpmfEntry("GPSF", 'L', 4, 1, u32s(3)),
+		gpmfEntry("SCAL", 'l', 4, 5, i32s(10000000, 10000000, 1000, 1000, 100)),
+		gpmfEntry("GPS5", 'l', 20, 1, i32s(476205000, -1223493000, 56000, 1500, 150)),
+	)
+	return gpmfNested("DEVC", accl, gps)
+}
+
+// mp4Options customizes the synthetic MP4 file built by buildMP4.
+type mp4Options struct {
+	format      string // sample description format of the track
+	stsz        []byte // sample size box contents, replacing the real table
+	chunkOffset uint32 // offset of the only chunk, replacing the real offset
+	truncate    int    // number of bytes cut from the end of the file
+}
+
+// buildMP4 writes an MP4 file whose metadata track holds one second-long
+// sample with the given payload, and returns its path.
+func buildMP4(t *testing.T, payload []byte, opts mp4Options) string {
+	t.Helper()
+
+	if opts.format == "" {
+		opts.format = "gpmd"
+	}
+
+	ftyp := mp4Box("ftyp", []byte("mp41"), u32s(0))
+	mdat := mp4Box("mdat", payload)
+	if opts.chunkOffset == 0 {
+		opts.chunkOffset = uint32(len(ftyp) + 8)
+	}
+	if opts.stsz == nil {
+		opts.stsz = u32s(0, 0, 1, uint32(len(payload)))
+	}
+
+	const timescale = 1000
+	stsd := append(u32s(0, 1, 16), opts.format...)
+	stsd = append(stsd, make([]byte, 8)...)
+
+	stbl := mp4Box("stbl",
+		mp4Box("stsd", stsd),
+		mp4Box("stts", u32s(0, 1, 1, timescale)),
+		mp4Box("stsc", u32s(0, 1, 1, 1, 1)),
+		mp4Box("stsz", opts.stsz),
+		mp4Box("stco", u32s(0, 1, opts.chunkOffset)),
+	)
+	trak := mp4Box("trak", mp4Box("mdia",
+		mp4Box("mdhd", u32s(0, 0, 0, timescale, timescale, 0)),
+		mp4Box("minf", stbl),
+	))
+	moov := mp4Box("moov", trak)
+
+	data := bytes.Join([][]byte{ftyp, mdat, moov}, nil)
+	data = data[:len(data)-opts.truncate]
+
+	path := filepath.Join(t.TempDir(), "GX010001.MP4")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExtract(t *testing.T) {
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	path := buildMP4(t, testPayload(), mp4Options{})
+
+	telemetry, err := Extract(path, start)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	wantAccl := []Vector3Sample{
+		{Time: start, X: 1, Y: 2, Z: 3},
+		{Time: start.Add(500 * time.Millisecond), X: -1, Y: -2, Z: -3},
+	}
+	if len(telemetry.Accelerometer) != len(wantAccl) {
+		t.Fatalf("got %d accelerometer samples, want %d", len(telemetry.Accelerometer), len(wantAccl))
+	}
+	for i, want := range wantAccl {
+		if got := telemetry.Accelerometer[i]; got != want {
+			t.Errorf("accelerometer sample %d = %+v, want %+v", i, got, want)
+		}
+	}
+
+	fix, ok := telemetry.FirstFix()
+	if !ok {
+		t.Fatal("FirstFix() found no fix")
+	}
+	if !approxEqual(fix.Latitude, 47.6205) || !approxEqual(fix.Longitude, -122.3493) || !approxEqual(fix.Altitude, 56) {
+		t.Errorf("FirstFix() = %+v, want 47.6205, -122.3493 at 56 m", fix)
+	}
+	if fix.Fix != 3 || !fix.Time.Equal(start) {
+		t.Errorf("FirstFix() fix = %d at %s, want 3 at %s", fix.Fix, fix.Time, start)
+	}
+}
+
+func TestExtractMalformed(t *testing.T) {
+	payload := testPayload()
+
+	tests := []struct {
+		name string
+		opts mp4Options
+		want error // nil means any error
+	}{
+		{
+			name: "no telemetry track",
+			opts: mp4Options{format: "avc1"},
+			want: ErrNoTelemetry,
+		},
+		{
+			name: "truncated moov",
+			opts: mp4Options{truncate: 10},
+		},
+		{
+			name: "fixed sample size exceeding file",
+			opts: mp4Options{stsz: u32s(0, 1<<20, 1<<20)},
+		},
+		{
+			name: "huge sample count",
+			opts: mp4Options{stsz: u32s(0, 1, math.MaxUint32)},
+		},
+		{
+			name: "sample size table shorter than count",
+			opts: mp4Options{stsz: u32s(0, 0, 1000, 8)},
+		},
+		{
+			name: "sample size past end of file",
+			opts: mp4Options{stsz: u32s(0, 0, 1, 1<<30)},
+		},
+		{
+			name: "chunk offset past end of file",
+			opts: mp4Options{chunkOffset: 1 << 30},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := buildMP4(t, payload, tt.opts)
+
+			_, err := Extract(path, time.Time{})
+			if err == nil {
+				t.Fatal("Extract() succeeded, want error")
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("Extract() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseKLV(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    []string // keys of the top-level entries
+		wantErr bool
+	}{
+		{
+			name: "padded values",
+			data: append(gpmfEntry("TYPE", 'c', 1, 3, []byte("sss")), gpmfEntry("TMPC", 'f', 4, 1, u32s(0))...),
+			want: []string{"TYPE", "TMPC"},
+		},
+		{
+			name: "stops at zero padding",
+			data: append(gpmfEntry("TMPC", 'f', 4, 1, u32s(0)), make([]byte, 8)...),
+			want: []string{"TMPC"},
+		},
+		{
+			name:    "truncated value",
+			data:    append(gpmfEntry("TMPC", 'f', 4, 1, u32s(0)), gpmfEntry("ACCL", 's', 6, 100, nil)...),
+			want:    []string{"TMPC"},
+			wantErr: true,
+		},
+		{
+			name:    "truncated nested entry",
+			data:    gpmfEntry("DEVC", 0, 1, 12, gpmfEntry("ACCL", 's', 6, 2, nil)),
+			want:    nil,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := parseKLV(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseKLV() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var keys []string
+			for _, e := range entries {
+				keys = append(keys, e.Key)
+			}
+			if !equalStrings(keys, tt.want) {
+				t.Errorf("parseKLV() keys = %v, want %v", keys, tt.want)
+			}
+		})
+	}
+}
+
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,187 @@
+package media
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ingestLogName is the file, kept in each media directory, that records when
+// the directory's files arrived. File modification times can't be used for
+// this, as they're set to the recording time.
+const ingestLogName = ".herosync-ingest.json"
+
+// IngestEntry describes the arrival of a single media file.
+type IngestEntry struct {
+	IngestedAt time.Time `json:"ingested_at"`
+	CombinedAt time.Time `json:"combined_at,omitzero"` // zero until combined into an outgoing video
+	RetiredAt  time.Time `json:"retired_at,omitzero"`  // set once deleted by a retention policy
+	Size       int64     `json:"size,omitempty"`       // size of the retired file
+}
+
+// Combined reports whether the file has been combined into an outgoing video.
+func (e *IngestEntry) Combined() bool {
+	return !e.CombinedAt.IsZero()
+}
+
+// Retired reports whether a file of the given size was deleted by a retention
+// policy, and so shouldn't be fetched again.
+func (e *IngestEntry) Retired(size int64) bool {
+	return !e.RetiredAt.IsZero() && e.Size == size
+}
+
+// IngestLog holds the arrival details of the media files in a directory,
+// keyed by filename.
+type IngestLog struct {
+	Files map[string]*IngestEntry `json:"files"`
+
+	path string
+}
+
+// LoadIngestLog reads the ingest log of dir, starting an empty one if the
+// directory doesn't have one yet.
+func LoadIngestLog(dir string) (*IngestLog, error) {
+	l := &IngestLog{path: filepath.Join(dir, ingestLogName)}
+
+	data, err := os.ReadFile(l.path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("reading ingest log: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, l); err != nil {
+			return nil, fmt.Errorf("decoding ingest log: %w", err)
+		}
+	}
+
+	if l.Files == nil {
+		l.Files = make(map[string]*IngestEntry)
+	}
+
+	return l, nil
+}
+
+// Get returns the entry for the given filename, if any.
+func (l *IngestLog) Get(name string) (*IngestEntry, bool) {
+	entry, ok := l.Files[name]
+	return entry, ok
+}
+
+// IngestedAt returns when the file arrived, or now for files that aren't in
+// the log, so that they're never treated as old.
+func (l *IngestLog) IngestedAt(name string, now time.Time) time.Time {
+	if entry, ok := l.Files[name]; ok {
+		return entry.IngestedAt
+	}
+	return now
+}
+
+// MarkIngested records that the files just arrived, replacing any earlier
+// entries for the same names, and persists the log.
+func (l *IngestLog) MarkIngested(names ...string) error {
+	now := time.Now()
+	for _, name := range names {
+		l.Files[name] = &IngestEntry{IngestedAt: now}
+	}
+	return l.save()
+}
+
+// MarkCombined records that the files have been combined into an outgoing
+// video, and persists the log.
+func (l *IngestLog) MarkCombined(names ...string) error {
+	now := time.Now()
+	for _, name := range names {
+		entry, ok := l.Files[name]
+		if !ok {
+			entry = &IngestEntry{IngestedAt: now}
+			l.Files[name] = entry
+		}
+		entry.CombinedAt = now
+	}
+	return l.save()
+}
+
+// MarkRetired records that a retention policy deleted the file, remembering
+// its size to tell it apart from a later file reusing the name, and persists
+// the log.
+func (l *IngestLog) MarkRetired(name string, size int64) error {
+	entry, ok := l.Files[name]
+	if !ok {
+		entry = &IngestEntry{IngestedAt: time.Now()}
+		l.Files[name] = entry
+	}
+	entry.RetiredAt = time.Now()
+	entry.Size = size
+	return l.save()
+}
+
+// Rename moves the entry of a renamed file to its new name, if there is one,
+// and persists the log.
+func (l *IngestLog) Rename(oldName, newName string) error {
+	entry, ok := l.Files[oldName]
+	if !ok {
+		return nil
+	}
+	delete(l.Files, oldName)
+	l.Files[newName] = entry
+	return l.save()
+}
+
+// Sync brings the log in line with the media files in its directory: files
+// missing from the log are added as arriving now, so that files from before
+// the log existed start aging, and entries of files that are gone are
+// dropped, except for retired ones. A retired file that's back, such as one
+// restored from the trash, starts aging again. The log is only persisted if
+// anything changed.
+func (l *IngestLog) Sync() error {
+	files, err := scanLocalFiles(filepath.Dir(l.path))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	changed := false
+	for name := range files {
+		entry, ok := l.Files[name]
+		switch {
+		case !ok:
+			l.Files[name] = &IngestEntry{IngestedAt: now}
+			changed = true
+		case !entry.RetiredAt.IsZero():
+			entry.IngestedAt = now
+			entry.RetiredAt = time.Time{}
+			entry.Size = 0
+			changed = true
+		}
+	}
+	for name, entry := range l.Files {
+		if _, ok := files[name]; !ok && entry.RetiredAt.IsZero() {
+			delete(l.Files, name)
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+	return l.save()
+}
+
+// save writes the log to disk atomically.
+func (l *IngestLog) save() error {
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o750); err != nil {
+		return fmt.Errorf("creating media directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding ingest log: %w", err)
+	}
+
+	tmpPath := l.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return fmt.Errorf("writing ingest log: %w", err)
+	}
+	return os.Rename(tmpPath, l.path)
+}
@@ -2,7 +2,9 @@ package media
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -80,8 +82,9 @@ type Inventory struct {
 	Files []File
 }
 
-// NewInventory creates an Inventory by comparing remote and local files.
-func NewInventory(ctx context.Context, client *gopro.Client, incomingDir, outgoingDir string) (*Inventory, error) {
+// NewInventory creates an Inventory by comparing remote and local files. The
+// corrections are applied to the creation times reported by the GoPro.
+func NewInventory(ctx context.Context, client *gopro.Client, incomingDir, outgoingDir string, corrections TimeCorrections) (*Inventory, error) {
 	mediaList, err := client.GetMediaList(ctx)
 	if err != nil {
 		return nil, err
@@ -98,7 +101,7 @@ func NewInventory(ctx context.Context, client *gopro.Client, incomingDir, outgoi
 	}
 
 	inventory := &Inventory{}
-	processRemoteFiles(mediaList, incomingFiles, inventory)
+	processRemoteFiles(mediaList, incomingFiles, corrections, inventory)
 	processIncomingFiles(incomingFiles, incomingDir, inventory)
 	if err := processOutgoingFiles(ctx, outgoingFiles, outgoingDir, inventory); err != nil {
 		return nil, err
@@ -130,6 +133,34 @@ func NewProcessedInventory(ctx context.Context, outgoingDir string) (*Inventory,
 	return inventory, nil
 }
 
+// NewLocalInventory creates an Inventory from the incoming and outgoing files
+// without contacting the GoPro. All incoming files are marked as OnlyLocal.
+func NewLocalInventory(ctx context.Context, incomingDirs []string, outgoingDir string) (*Inventory, error) {
+	inventory := &Inventory{}
+	for _, incomingDir := range incomingDirs {
+		incomingFiles, err := scanLocalFiles(incomingDir)
+		if err != nil {
+			return nil, err
+		}
+		processIncomingFiles(incomingFiles, incomingDir, inventory)
+	}
+
+	outgoingFiles, err := scanLocalFiles(outgoingDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := processOutgoingFiles(ctx, outgoingFiles, outgoingDir, inventory); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(inventory.Files, func(i, j int) bool {
+		return inventory.Files[i].CreatedAt.Before(inventory.Files[j].CreatedAt)
+	})
+
+	return inventory, nil
+}
+
 // scanLocalFiles builds a map of local files (filename -> os.FileInfo).
 func scanLocalFiles(dir string) (map[string]os.FileInfo, error) {
 	absDir, err := filepath.Abs(dir)
@@ -140,6 +171,9 @@ func scanLocalFiles(dir string) (map[string]os.FileInfo, error) {
 	files := make(map[string]os.FileInfo)
 
 	entries, err := os.ReadDir(absDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return files, nil // nothing downloaded here yet
+	}
 	if err != nil {
 		return nil, fmt.Errorf("reading directory: %w", err)
 	}
@@ -167,7 +201,7 @@ func scanLocalFiles(dir string) (map[string]os.FileInfo, error) {
 }
 
 // processRemoteFiles adds files from GoPro and updates their status if found locally in incoming directory.
-func processRemoteFiles(mediaList *gopro.MediaList, incomingFiles map[string]os.FileInfo, inventory *Inventory) {
+func processRemoteFiles(mediaList *gopro.MediaList, incomingFiles map[string]os.FileInfo, corrections TimeCorrections, inventory *Inventory) {
 	for _, media := range mediaList.Media {
 		for _, file := range media.Items {
 			localFileInfo, localFileExists := incomingFiles[file.Filename]
@@ -185,7 +219,7 @@ func processRemoteFiles(mediaList *gopro.MediaList, incomingFiles map[string]os.
 			mediaFile := File{
 				Directory: media.Directory,
 				Filename:  file.Filename,
-				CreatedAt: file.CreatedAt,
+				CreatedAt: corrections.Apply(file.Filename, file.CreatedAt),
 				Size:      file.Size,
 				Status:    status,
 			}
@@ -291,6 +325,25 @@ func (f File) String() string {
 	return f.DisplayInfo
 }
 
+// NeedsDownload reports whether the file should be downloaded. Files that a
+// retention policy already deleted locally, according to the ingest log, are
+// only fetched again if forced.
+func (f File) NeedsDownload(force bool, ingest *IngestLog) bool {
+	switch f.Status {
+	case OnlyRemote:
+		if ingest != nil {
+			if entry, ok := ingest.Get(f.Filename); ok && entry.Retired(f.Size) {
+				return force
+			}
+		}
+		return true
+	case OutOfSync, InSync:
+		return force
+	default:
+		return false
+	}
+}
+
 // FilterByDate returns a new Inventory containing only files created on the specified date.
 func (inv *Inventory) FilterByDate(date time.Time) (*Inventory, error) {
 	filtered := &Inventory{}
@@ -384,11 +437,12 @@ func (inv *Inventory) TotalSize() int64 {
 	return totalSize
 }
 
-// PendingSize calculates the total size (in bytes) of files in the Inventory with the OnlyRemote status.
-func (inv *Inventory) PendingSize() int64 {
+// PendingSize calculates the total size (in bytes) of the files in the
+// Inventory that need downloading, as reported by NeedsDownload.
+func (inv *Inventory) PendingSize(force bool, ingest *IngestLog) int64 {
 	var totalSize int64
 	for _, file := range inv.Files {
-		if file.Status == OnlyRemote {
+		if file.NeedsDownload(force, ingest) {
 			totalSize += file.Size
 		}
 	}
@@ -0,0 +1,45 @@
+package media
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInventoryPendingSize(t *testing.T) {
+	inv := &Inventory{Files: []File{
+		{Filename: "GX010001.MP4", Size: 1, Status: OnlyRemote},
+		{Filename: "GX010002.MP4", Size: 10, Status: OnlyRemote},  // retired at this size
+		{Filename: "GX010003.MP4", Size: 100, Status: OnlyRemote}, // retired at another size
+		{Filename: "GX010004.MP4", Size: 1000, Status: OutOfSync},
+		{Filename: "GX010005.MP4", Size: 10000, Status: InSync},
+		{Filename: "GX010006.MP4", Size: 100000, Status: OnlyLocal},
+		{Filename: "GX010007.MP4", Size: 1000000, Status: Processed},
+	}}
+
+	retired := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	ingest := &IngestLog{Files: map[string]*IngestEntry{
+		"GX010002.MP4": {RetiredAt: retired, Size: 10},
+		"GX010003.MP4": {RetiredAt: retired, Size: 99},
+		"GX010004.MP4": {RetiredAt: retired, Size: 1000},
+	}}
+
+	tests := []struct {
+		name   string
+		force  bool
+		ingest *IngestLog
+		want   int64
+	}{
+		{name: "without ingest log", ingest: nil, want: 111},
+		{name: "forced without ingest log", force: true, ingest: nil, want: 11111},
+		{name: "retired files skipped", ingest: ingest, want: 101},
+		{name: "forced includes retired files", force: true, ingest: ingest, want: 11111},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inv.PendingSize(tt.force, tt.ingest); got != tt.want {
+				t.Errorf("PendingSize(%t) = %d, want %d", tt.force, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package media
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Stream describes a single stream within a media container.
+type Stream struct {
+	Index     int               `json:"index"`
+	CodecType string            `json:"codec_type"`       // video, audio, data, etc.
+	CodecName string            `json:"codec_name"`       // empty for unknown codecs
+	CodecTag  string            `json:"codec_tag_string"` // e.g., "avc1", "tmcd", "gpmd"
+	Width     int               `json:"width"`            // video streams only
+	Height    int               `json:"height"`           // video streams only
+	Tags      map[string]string `json:"tags"`
+}
+
+// HandlerName returns the stream's handler name (e.g., "GoPro MET"), if any.
+func (s Stream) HandlerName() string {
+	return s.Tags["handler_name"]
+}
+
+// ProbeStreams lists the streams of a media file using ffprobe.
+func ProbeStreams(ctx context.Context, path string) ([]Stream, error) {
+	cmd := exec.CommandContext(
+		ctx,
+		"ffprobe",
+		"-v",
+		"error",
+		"-show_entries",
+		"stream=index,codec_type,codec_name,codec_tag_string,width,height:stream_tags=handler_name",
+		"-of",
+		"json",
+		path,
+	)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to probe streams: %w", err)
+	}
+
+	var result struct {
+		Streams []Stream `json:"streams"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse stream list: %w", err)
+	}
+
+	return result.Streams, nil
+}
+
+// ProbeDuration returns the duration of a media file using ffprobe.
+func ProbeDuration(ctx context.Context, path string) (time.Duration, error) {
+	ms, err := getVideoDuration(ctx, path)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// FFmpegVersion returns the version of the installed ffmpeg (e.g., "7.1").
+func FFmpegVersion(ctx context.Context) (string, error) {
+	output, err := exec.CommandContext(ctx, "ffmpeg", "-version").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get ffmpeg version: %w", err)
+	}
+
+	// The first line reads "ffmpeg version <VERSION> Copyright ...".
+	fields := strings.Fields(string(output))
+	if len(fields) < 3 || fields[1] != "version" {
+		return "", fmt.Errorf("unexpected ffmpeg version output")
+	}
+	return fields[2], nil
+}
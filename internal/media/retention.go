@@ -0,0 +1,90 @@
+package media
+
+import (
+	"os"
+	"sort"
+	"time"
+)
+
+// RetentionPolicy limits how much media is kept in a directory. Zero values
+// disable the respective limit.
+type RetentionPolicy struct {
+	MaxSize uint64        // Maximum total size of media files in bytes
+	MaxAge  time.Duration // Maximum age of media files, based on when they arrived
+}
+
+// Enabled reports whether the policy limits anything.
+func (p RetentionPolicy) Enabled() bool {
+	return p.MaxSize > 0 || p.MaxAge > 0
+}
+
+// retentionFile is a media file considered by a retention policy.
+type retentionFile struct {
+	name       string
+	size       uint64
+	ingestedAt time.Time
+	deletable  bool
+}
+
+// PlanRetention returns the names of the media files in dir that would need
+// to be deleted, earliest arrival first, for the policy to be satisfied. Only
+// files that deletable approves are deleted, but every file counts toward the
+// size limit. Arrival times come from the directory's ingest log.
+func PlanRetention(dir string, policy RetentionPolicy, log *IngestLog, deletable func(name string, info os.FileInfo) bool) ([]string, error) {
+	if !policy.Enabled() {
+		return nil, nil
+	}
+
+	files, err := scanLocalFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	candidates := make([]retentionFile, 0, len(files))
+	for name, info := range files {
+		candidates = append(candidates, retentionFile{
+			name:       name,
+			size:       uint64(info.Size()),
+			ingestedAt: log.IngestedAt(name, now),
+			deletable:  deletable(name, info),
+		})
+	}
+
+	return planRetention(candidates, policy, now), nil
+}
+
+// planRetention picks the files to delete, earliest arrival first, until the
+// policy is satisfied as of now.
+func planRetention(files []retentionFile, policy RetentionPolicy, now time.Time) []string {
+	var totalSize uint64
+	for _, f := range files {
+		totalSize += f.size
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].ingestedAt.Equal(files[j].ingestedAt) {
+			return files[i].name < files[j].name
+		}
+		return files[i].ingestedAt.Before(files[j].ingestedAt)
+	})
+
+	cutoff := now.Add(-policy.MaxAge)
+
+	var planned []string
+	for _, f := range files {
+		tooOld := policy.MaxAge > 0 && f.ingestedAt.Before(cutoff)
+		tooBig := policy.MaxSize > 0 && totalSize > policy.MaxSize
+		if !tooOld && !tooBig {
+			break // remaining files arrived later and fit within the size limit
+		}
+		if !f.deletable {
+			continue
+		}
+
+		planned = append(planned, f.name)
+		totalSize -= f.size
+	}
+
+	return planned
+}
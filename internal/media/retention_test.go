@@ -0,0 +1,153 @@
+package media
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPlanRetention(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	daysAgo := func(n int) time.Time { return now.AddDate(0, 0, -n) }
+
+	tests := []struct {
+		name   string
+		files  []retentionFile
+		policy RetentionPolicy
+		want   []string
+	}{
+		{
+			name: "age deletes only old files",
+			files: []retentionFile{
+				{name: "old.mp4", size: 10, ingestedAt: daysAgo(10), deletable: true},
+				{name: "new.mp4", size: 10, ingestedAt: daysAgo(1), deletable: true},
+			},
+			policy: RetentionPolicy{MaxAge: 7 * 24 * time.Hour},
+			want:   []string{"old.mp4"},
+		},
+		{
+			name: "size deletes earliest arrivals first",
+			files: []retentionFile{
+				{name: "c.mp4", size: 40, ingestedAt: daysAgo(1), deletable: true},
+				{name: "a.mp4", size: 40, ingestedAt: daysAgo(3), deletable: true},
+				{name: "b.mp4", size: 40, ingestedAt: daysAgo(2), deletable: true},
+			},
+			policy: RetentionPolicy{MaxSize: 50},
+			want:   []string{"a.mp4", "b.mp4"},
+		},
+		{
+			name: "undeletable files are kept but count toward size",
+			files: []retentionFile{
+				{name: "uncombined.mp4", size: 40, ingestedAt: daysAgo(3), deletable: false},
+				{name: "combined.mp4", size: 40, ingestedAt: daysAgo(2), deletable: true},
+				{name: "newest.mp4", size: 40, ingestedAt: daysAgo(1), deletable: true},
+			},
+			policy: RetentionPolicy{MaxSize: 50},
+			want:   []string{"combined.mp4", "newest.mp4"},
+		},
+		{
+			name: "undeletable old files are kept",
+			files: []retentionFile{
+				{name: "unpublished.mp4", size: 10, ingestedAt: daysAgo(30), deletable: false},
+			},
+			policy: RetentionPolicy{MaxAge: 24 * time.Hour},
+			want:   nil,
+		},
+		{
+			name: "within limits",
+			files: []retentionFile{
+				{name: "a.mp4", size: 10, ingestedAt: daysAgo(1), deletable: true},
+			},
+			policy: RetentionPolicy{MaxSize: 100, MaxAge: 7 * 24 * time.Hour},
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := planRetention(tt.files, tt.policy, now)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("planRetention() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanRetentionUsesIngestTime(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "GX010001.MP4")
+	if err := os.WriteFile(path, []byte("video"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// The mtime is the recording time, long before the file arrived.
+	recorded := time.Now().AddDate(-1, 0, 0)
+	if err := os.Chtimes(path, recorded, recorded); err != nil {
+		t.Fatal(err)
+	}
+
+	log, err := LoadIngestLog(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := log.MarkIngested("GX010001.MP4"); err != nil {
+		t.Fatal(err)
+	}
+
+	all := func(string, os.FileInfo) bool { return true }
+	planned, err := PlanRetention(dir, RetentionPolicy{MaxAge: 24 * time.Hour}, log, all)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(planned) != 0 {
+		t.Errorf("PlanRetention() = %v, want nothing for a file that just arrived", planned)
+	}
+}
+
+func TestIngestLogSync(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"kept.mp4", "untracked.mp4"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	log, err := LoadIngestLog(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := log.MarkCombined("kept.mp4", "gone.mp4", "retired.mp4"); err != nil {
+		t.Fatal(err)
+	}
+	if err := log.MarkRetired("retired.mp4", 42); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := log.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := LoadIngestLog(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if entry, ok := reloaded.Get("kept.mp4"); !ok || !entry.Combined() {
+		t.Errorf("kept.mp4: got %+v, want a combined entry", entry)
+	}
+	if entry, ok := reloaded.Get("untracked.mp4"); !ok || entry.Combined() {
+		t.Errorf("untracked.mp4: got %+v, want a new uncombined entry", entry)
+	}
+	if _, ok := reloaded.Get("gone.mp4"); ok {
+		t.Error("gone.mp4: entry kept for a missing file")
+	}
+	entry, ok := reloaded.Get("retired.mp4")
+	if !ok || !entry.Retired(42) {
+		t.Errorf("retired.mp4: got %+v, want a retired entry", entry)
+	}
+	if entry.Retired(43) {
+		t.Error("retired.mp4: a file of another size reusing the name counts as retired")
+	}
+}
@@ -0,0 +1,41 @@
+package media
+
+import (
+	"time"
+
+	"github.com/EarthmanMuons/herosync/internal/gopro"
+)
+
+// TimeCorrection shifts the creation time of media recorded while the camera's
+// clock was wrong. It applies either to a single media ID, or to all media the
+// camera reports as recorded on a date (in the local time zone).
+type TimeCorrection struct {
+	MediaID int
+	Date    string // YYYY-MM-DD
+	Offset  time.Duration
+}
+
+// TimeCorrections is an ordered list of corrections.
+type TimeCorrections []TimeCorrection
+
+// Apply returns the corrected creation time of the named file. Corrections for
+// a media ID take precedence over corrections for a date.
+func (tc TimeCorrections) Apply(filename string, createdAt time.Time) time.Time {
+	info := gopro.ParseFilename(filename)
+	if info.IsValid {
+		for _, c := range tc {
+			if c.MediaID != 0 && c.MediaID == info.MediaID {
+				return createdAt.Add(c.Offset)
+			}
+		}
+	}
+
+	date := createdAt.Local().Format(time.DateOnly)
+	for _, c := range tc {
+		if c.Date != "" && c.Date == date {
+			return createdAt.Add(c.Offset)
+		}
+	}
+
+	return createdAt
+}
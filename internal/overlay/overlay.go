@@ -0,0 +1,301 @@
+// Package overlay renders GPS telemetry gauges as an Advanced SubStation Alpha
+// (ASS) subtitle script, which FFmpeg's "ass" filter can burn into a video.
+//
+// Upstream docs: https://github.com/libass/libass/wiki/ASS-File-Format-Guide
+package overlay
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/EarthmanMuons/herosync/internal/gpmf"
+)
+
+// Gauge is a single element of the overlay.
+type Gauge string
+
+const (
+	Speed    Gauge = "speed"    // ground speed
+	Altitude Gauge = "altitude" // height above the WGS 84 ellipsoid
+	Clock    Gauge = "time"     // local time of day of the recording
+	Map      Gauge = "map"      // the whole track, with the current position
+)
+
+// ParseGauges converts gauge names to Gauges.
+func ParseGauges(names []string) ([]Gauge, error) {
+	gauges := make([]Gauge, 0, len(names))
+	for _, name := range names {
+		switch g := Gauge(strings.ToLower(strings.TrimSpace(name))); g {
+		case Speed, Altitude, Clock, Map:
+			gauges = append(gauges, g)
+		case "":
+			// skip
+		default:
+			return nil, fmt.Errorf("invalid overlay gauge: %q (choose speed, altitude, time, or map)", name)
+		}
+	}
+	return gauges, nil
+}
+
+// Options controls how the overlay is rendered.
+type Options struct {
+	Gauges   []Gauge
+	Width    int           // video width in pixels
+	Height   int           // video height in pixels
+	Imperial bool          // use mph and feet instead of km/h and meters
+	Interval time.Duration // how often the gauges update; defaults to 500ms, not every frame
+}
+
+// Sample is a GPS sample positioned on the timeline of the rendered video.
+type Sample struct {
+	Offset time.Duration
+	gpmf.GPSSample
+}
+
+const (
+	maxMapPoints   = 500 // limit on points in the drawn track
+	defaultUpdates = 500 * time.Millisecond
+)
+
+// WriteASS writes an ASS script covering duration that displays the selected
+// gauges for the given samples, which must be sorted by offset. The gauges
+// show the latest fix at the start of each update interval, so they step
+// rather than changing with every frame.
+func WriteASS(w io.Writer, samples []Sample, duration time.Duration, opts Options) error {
+	if opts.Width <= 0 || opts.Height <= 0 {
+		return fmt.Errorf("invalid overlay size: %dx%d", opts.Width, opts.Height)
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = defaultUpdates
+	}
+
+	var fixes []Sample
+	for _, s := range samples {
+		if s.HasFix() {
+			fixes = append(fixes, s)
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	writeHeader(bw, opts)
+
+	var showMap, showText bool
+	for _, g := range opts.Gauges {
+		if g == Map {
+			showMap = true
+		} else {
+			showText = true
+		}
+	}
+
+	var m *miniMap
+	if showMap && len(fixes) > 0 {
+		m = newMiniMap(fixes, opts)
+		m.writeTrack(bw, duration)
+	}
+
+	next := 0
+	for start := time.Duration(0); start < duration; start += opts.Interval {
+		end := min(start+opts.Interval, duration)
+
+		// Advance to the most recent fix at or before this interval.
+		for next < len(fixes) && fixes[next].Offset <= start {
+			next++
+		}
+		if next == 0 {
+			continue
+		}
+		current := fixes[next-1]
+
+		if showText {
+			writeEvent(bw, 0, start, end, "Gauge", gaugeText(current, opts))
+		}
+		if m != nil {
+			m.writePosition(bw, start, end, current)
+		}
+	}
+
+	return bw.Flush()
+}
+
+// writeHeader writes the script info and style sections.
+func writeHeader(w io.Writer, opts Options) {
+	fontSize := opts.Height / 18
+	margin := opts.Height / 30
+
+	fmt.Fprintf(w, "[Script Info]\n")
+	fmt.Fprintf(w, "ScriptType: v4.00+\n")
+	fmt.Fprintf(w, "PlayResX: %d\n", opts.Width)
+	fmt.Fprintf(w, "PlayResY: %d\n", opts.Height)
+	fmt.Fprintf(w, "WrapStyle: 2\n")
+	fmt.Fprintf(w, "ScaledBorderAndShadow: yes\n\n")
+
+	fmt.Fprintf(w, "[V4+ Styles]\n")
+	fmt.Fprintf(w, "Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, Alignment, MarginL, MarginR, MarginV, Encoding\n")
+	fmt.Fprintf(w, "Style: Gauge,Sans,%d,&H00FFFFFF,&H00FFFFFF,&H00000000,&H80000000,1,0,0,0,100,100,0,0,1,%d,0,1,%d,%d,%d,1\n", fontSize, max(1, fontSize/12), margin, margin, margin)
+	fmt.Fprintf(w, "Style: Map,Sans,%d,&H00FFFFFF,&H00FFFFFF,&H00000000,&H00000000,0,0,0,0,100,100,0,0,1,0,0,7,0,0,0,1\n\n", fontSize)
+
+	fmt.Fprintf(w, "[Events]\n")
+	fmt.Fprintf(w, "Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n")
+}
+
+// writeEvent writes a single dialogue line.
+func writeEvent(w io.Writer, layer int, start, end time.Duration, style, text string) {
+	fmt.Fprintf(w, "Dialogue: %d,%s,%s,%s,,0,0,0,,%s\n", layer, formatTimestamp(start), formatTimestamp(end), style, text)
+}
+
+// gaugeText returns the text gauges for a sample, one per line.
+func gaugeText(s Sample, opts Options) string {
+	var lines []string
+	for _, g := range opts.Gauges {
+		switch g {
+		case Speed:
+			if opts.Imperial {
+				lines = append(lines, fmt.Sprintf("%.0f mph", s.Speed2D*2.236936))
+			} else {
+				lines = append(lines, fmt.Sprintf("%.0f km/h", s.Speed2D*3.6))
+			}
+		case Altitude:
+			if opts.Imperial {
+				lines = append(lines, fmt.Sprintf("%.0f ft", s.Altitude*3.28084))
+			} else {
+				lines = append(lines, fmt.Sprintf("%.0f m", s.Altitude))
+			}
+		case Clock:
+			lines = append(lines, s.Time.Local().Format("15:04:05"))
+		}
+	}
+	return strings.Join(lines, `\N`)
+}
+
+// miniMap projects the track into a square box in the top-right corner.
+type miniMap struct {
+	fixes          []Sample
+	left, top      float64 // position of the box
+	size           float64 // width and height of the box
+	minLat, minLon float64
+	scale          float64 // pixels per projected degree
+	lonFactor      float64 // shrinks longitude at higher latitudes
+	offsetX        float64 // centers the track horizontally
+	offsetY        float64 // centers the track vertically
+}
+
+func newMiniMap(fixes []Sample, opts Options) *miniMap {
+	size := float64(opts.Height) / 4
+	margin := float64(opts.Height) / 30
+
+	m := &miniMap{
+		fixes:  fixes,
+		left:   float64(opts.Width) - size - margin,
+		top:    margin,
+		size:   size,
+		minLat: math.Inf(1),
+		minLon: math.Inf(1),
+	}
+
+	maxLat, maxLon := math.Inf(-1), math.Inf(-1)
+	var sumLat float64
+	for _, s := range fixes {
+		m.minLat = math.Min(m.minLat, s.Latitude)
+		m.minLon = math.Min(m.minLon, s.Longitude)
+		maxLat = math.Max(maxLat, s.Latitude)
+		maxLon = math.Max(maxLon, s.Longitude)
+		sumLat += s.Latitude
+	}
+	m.lonFactor = math.Cos(sumLat / float64(len(fixes)) * math.Pi / 180)
+
+	width := (maxLon - m.minLon) * m.lonFactor
+	height := maxLat - m.minLat
+	padding := size / 10
+	if extent := math.Max(width, height); extent > 0 {
+		m.scale = (size - 2*padding) / extent
+	}
+	m.offsetX = (size - width*m.scale) / 2
+	m.offsetY = (size - height*m.scale) / 2
+
+	return m
+}
+
+// project converts a position to pixel coordinates within the box.
+func (m *miniMap) project(s Sample) (float64, float64) {
+	x := m.offsetX + (s.Longitude-m.minLon)*m.lonFactor*m.scale
+	y := m.size - m.offsetY - (s.Latitude-m.minLat)*m.scale
+	return x, y
+}
+
+// writeTrack writes the map background and the whole track for the duration.
+//
+// Renderers differ in whether a drawing is anchored at its origin or at the
+// corner of its bounding box, so every drawing is shifted to start at (0, 0)
+// and positioned with \pos instead.
+func (m *miniMap) writeTrack(w io.Writer, duration time.Duration) {
+	background := fmt.Sprintf(`{\an7\pos(%.0f,%.0f)\bord0\shad0\1c&H000000&\1a&H80&\p1}m 0 0 l %.0f 0 %.0f %.0f 0 %.0f{\p0}`,
+		m.left, m.top, m.size, m.size, m.size, m.size)
+	writeEvent(w, 1, 0, duration, "Map", background)
+
+	step := max(1, len(m.fixes)/maxMapPoints)
+	var xs, ys []float64
+	for i := 0; i < len(m.fixes); i += step {
+		x, y := m.project(m.fixes[i])
+		xs = append(xs, x)
+		ys = append(ys, y)
+	}
+	minX, minY := slices.Min(xs), slices.Min(ys)
+
+	points := make([]string, len(xs))
+	for i := range xs {
+		points[i] = fmt.Sprintf("%.1f %.1f", xs[i]-minX, ys[i]-minY)
+	}
+
+	// ASS drawings are closed polygons, so trace the track out and back to
+	// avoid a line joining its ends.
+	track := fmt.Sprintf(`{\an7\pos(%.0f,%.0f)\bord%.0f\shad0\1a&HFF&\3c&HFFFFFF&\p1}m %s{\p0}`,
+		m.left+minX, m.top+minY, math.Max(1, m.size/100), outAndBack(points))
+	writeEvent(w, 2, 0, duration, "Map", track)
+}
+
+// writePosition writes a dot marking the current position on the track.
+func (m *miniMap) writePosition(w io.Writer, start, end time.Duration, s Sample) {
+	x, y := m.project(s)
+	r := math.Max(3, m.size/40)
+	k := r * 0.5523 // approximates a circle with Bézier curves
+	d := 2 * r
+	dot := fmt.Sprintf(`{\an7\pos(%.0f,%.0f)\bord0\shad0\1c&H0000FF&\p1}m 0 %.1f b 0 %.1f %.1f 0 %.1f 0 b %.1f 0 %.1f %.1f %.1f %.1f b %.1f %.1f %.1f %.1f %.1f %.1f b %.1f %.1f 0 %.1f 0 %.1f{\p0}`,
+		m.left+x-r, m.top+y-r,
+		r,
+		r-k, r-k, r,
+		r+k, d, r-k, d, r,
+		d, r+k, r+k, d, r, d,
+		r-k, d, r+k, r,
+	)
+	writeEvent(w, 3, start, end, "Map", dot)
+}
+
+// outAndBack joins the points into a drawing path that goes out along the
+// track and returns along the same points.
+func outAndBack(points []string) string {
+	if len(points) < 2 {
+		return strings.Join(points, " ")
+	}
+	var b strings.Builder
+	b.WriteString(points[0])
+	b.WriteString(" l")
+	for _, p := range points[1:] {
+		b.WriteString(" " + p)
+	}
+	for i := len(points) - 2; i >= 0; i-- {
+		b.WriteString(" " + points[i])
+	}
+	return b.String()
+}
+
+// formatTimestamp formats a duration as an ASS timestamp (H:MM:SS.cc).
+func formatTimestamp(d time.Duration) string {
+	cs := d.Milliseconds() / 10
+	return fmt.Sprintf("%d:%02d:%02d.%02d", cs/360000, cs/6000%60, cs/100%60, cs%100)
+}
@@ -0,0 +1,133 @@
+// Package pubrecord keeps a local record of outgoing videos that have been
+// published, along with the final state reported by the publishing backend.
+package pubrecord
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// State represents the publication state of an outgoing video.
+type State string
+
+const (
+	Uploaded  State = "uploaded"  // Upload finished, processing outcome unknown
+	Processed State = "processed" // Backend finished processing the video
+	Failed    State = "failed"    // Backend failed to process the video
+	Rejected  State = "rejected"  // Backend rejected the video
+	Deleted   State = "deleted"   // Video was deleted from the backend
+)
+
+// IsFinal reports whether the backend has finished with the video, successfully or not.
+func (s State) IsFinal() bool {
+	return s != Uploaded
+}
+
+// Entry describes the publication of a single outgoing file.
+type Entry struct {
+	Filename   string    `json:"filename"`
+	Backend    string    `json:"backend"`
+	VideoID    string    `json:"video_id"`
+	Title      string    `json:"title"`
+	Size       int64     `json:"size"`
+	UploadedAt time.Time `json:"uploaded_at"`
+	State      State     `json:"state"`
+	Reason     string    `json:"reason,omitempty"`
+	CheckedAt  time.Time `json:"checked_at,omitzero"`
+}
+
+// Confirmed reports whether the publication of the file has been verified.
+func (e *Entry) Confirmed() bool {
+	return e.State == Processed
+}
+
+// Matches reports whether the entry describes the given local file, guarding
+// against a newer file that reuses the name of one published earlier.
+func (e *Entry) Matches(info os.FileInfo) bool {
+	return info.Name() == e.Filename && info.Size() == e.Size
+}
+
+// Record holds the publication entries for all outgoing files, keyed by filename.
+type Record struct {
+	Entries map[string]*Entry `json:"entries"`
+
+	path string
+}
+
+// Load reads the record at path, starting an empty one if the file doesn't exist.
+func Load(path string) (*Record, error) {
+	r := &Record{path: path}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("reading publication record: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, r); err != nil {
+			return nil, fmt.Errorf("decoding publication record: %w", err)
+		}
+	}
+
+	if r.Entries == nil {
+		r.Entries = make(map[string]*Entry)
+	}
+
+	return r, nil
+}
+
+// Get returns the entry for the given filename, if any.
+func (r *Record) Get(filename string) (*Entry, bool) {
+	entry, ok := r.Entries[filename]
+	return entry, ok
+}
+
+// Put adds or replaces an entry and persists the record.
+func (r *Record) Put(entry *Entry) error {
+	r.Entries[entry.Filename] = entry
+	return r.save()
+}
+
+// Rename moves the entry of a renamed file to its new name, if there is one,
+// and persists the record.
+func (r *Record) Rename(oldFilename, newFilename string) error {
+	entry, ok := r.Entries[oldFilename]
+	if !ok {
+		return nil
+	}
+	delete(r.Entries, oldFilename)
+	entry.Filename = newFilename
+	r.Entries[newFilename] = entry
+	return r.save()
+}
+
+// Pending returns the entries that are still awaiting a final state.
+func (r *Record) Pending() []*Entry {
+	var pending []*Entry
+	for _, entry := range r.Entries {
+		if !entry.State.IsFinal() {
+			pending = append(pending, entry)
+		}
+	}
+	return pending
+}
+
+// save writes the record to disk atomically.
+func (r *Record) save() error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o750); err != nil {
+		return fmt.Errorf("creating publication record directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding publication record: %w", err)
+	}
+
+	tmpPath := r.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return fmt.Errorf("writing publication record: %w", err)
+	}
+	return os.Rename(tmpPath, r.path)
+}
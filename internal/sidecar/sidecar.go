@@ -0,0 +1,113 @@
+// Package sidecar reads and writes the JSON metadata file kept next to each
+// outgoing video, describing how the video was made.
+package sidecar
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/EarthmanMuons/herosync/internal/fsutil"
+)
+
+// Extension is appended to the video's base name to form the sidecar's name.
+const Extension = ".herosync.json"
+
+// Source describes one of the original files a video was combined from.
+type Source struct {
+	Filename  string    `json:"filename"`
+	MediaID   int       `json:"media_id,omitempty"`
+	Chapter   int       `json:"chapter,omitempty"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Camera describes the camera that recorded the sources.
+type Camera struct {
+	Model    string `json:"model"`
+	Serial   string `json:"serial"`
+	Firmware string `json:"firmware"`
+}
+
+// FFmpeg describes the FFmpeg runs that produced the video.
+type FFmpeg struct {
+	Version  string   `json:"version,omitempty"`
+	Commands []string `json:"commands"`
+}
+
+// Sidecar holds the metadata of a single outgoing video.
+type Sidecar struct {
+	Video      string    `json:"video"`
+	CreatedAt  time.Time `json:"created_at"`
+	CombinedAt time.Time `json:"combined_at"`
+	GroupBy    string    `json:"group_by"`
+	Sources    []Source  `json:"sources"`
+	Camera     *Camera   `json:"camera,omitempty"`
+	FFmpeg     FFmpeg    `json:"ffmpeg"`
+}
+
+// Path returns the sidecar path for the video at videoPath.
+func Path(videoPath string) string {
+	return strings.TrimSuffix(videoPath, filepath.Ext(videoPath)) + Extension
+}
+
+// Read loads the sidecar of the video at videoPath. The error wraps
+// fs.ErrNotExist if the video has no sidecar.
+func Read(videoPath string) (*Sidecar, error) {
+	data, err := os.ReadFile(Path(videoPath))
+	if err != nil {
+		return nil, fmt.Errorf("reading sidecar: %w", err)
+	}
+
+	var s Sidecar
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("decoding sidecar: %w", err)
+	}
+	return &s, nil
+}
+
+// Write saves the sidecar next to the video at videoPath.
+func Write(videoPath string, s *Sidecar) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding sidecar: %w", err)
+	}
+
+	if err := os.WriteFile(Path(videoPath), append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("writing sidecar: %w", err)
+	}
+	return nil
+}
+
+// Move renames the sidecar of the video at oldVideoPath to follow the video to
+// newVideoPath. Videos without a sidecar are ignored.
+func Move(oldVideoPath, newVideoPath string) error {
+	err := fsutil.MoveFile(Path(oldVideoPath), Path(newVideoPath))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("moving sidecar: %w", err)
+	}
+	return nil
+}
+
+// Remove deletes the sidecar of the video at videoPath, if any.
+func Remove(videoPath string) error {
+	err := os.Remove(Path(videoPath))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("removing sidecar: %w", err)
+	}
+	return nil
+}
+
+// SourceFilenames returns the names of the source files.
+func (s *Sidecar) SourceFilenames() []string {
+	names := make([]string, 0, len(s.Sources))
+	for _, src := range s.Sources {
+		names = append(names, src.Filename)
+	}
+	return names
+}
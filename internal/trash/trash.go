@@ -0,0 +1,185 @@
+// Package trash provides a local trash directory for deleted media files, so
+// that accidental deletions can be restored until they are purged.
+package trash
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/EarthmanMuons/herosync/internal/fsutil"
+)
+
+const manifestName = "manifest.json"
+
+// Item describes a single file held in the trash.
+type Item struct {
+	Name         string    `json:"name"`          // Filename within the trash directory
+	OriginalPath string    `json:"original_path"` // Absolute path the file was moved from
+	Size         int64     `json:"size"`
+	Reason       string    `json:"reason"`
+	TrashedAt    time.Time `json:"trashed_at"`
+}
+
+// Filename returns the original base name of the trashed file.
+func (i *Item) Filename() string {
+	return filepath.Base(i.OriginalPath)
+}
+
+// Bin is a trash directory along with its manifest of trashed files.
+type Bin struct {
+	Items []*Item `json:"items"`
+
+	dir string
+}
+
+// Open loads the trash in dir, creating an empty one if it doesn't exist yet.
+func Open(dir string) (*Bin, error) {
+	b := &Bin{dir: dir}
+
+	data, err := os.ReadFile(filepath.Join(dir, manifestName))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("reading trash manifest: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, b); err != nil {
+			return nil, fmt.Errorf("decoding trash manifest: %w", err)
+		}
+	}
+
+	return b, nil
+}
+
+// Dir returns the trash directory.
+func (b *Bin) Dir() string {
+	return b.dir
+}
+
+// Move moves the file at path into the trash, recording why it was deleted.
+func (b *Bin) Move(path, reason string) (*Item, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("getting absolute path: %w", err)
+	}
+
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return nil, err
+	}
+
+	dst, err := fsutil.GenerateUniqueFilename(filepath.Join(b.dir, info.Name()))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := fsutil.MoveFile(absPath, dst); err != nil {
+		return nil, fmt.Errorf("moving %s to trash: %w", info.Name(), err)
+	}
+
+	item := &Item{
+		Name:         filepath.Base(dst),
+		OriginalPath: absPath,
+		Size:         info.Size(),
+		Reason:       reason,
+		TrashedAt:    time.Now(),
+	}
+	b.Items = append(b.Items, item)
+
+	return item, b.save()
+}
+
+// Restore moves a trashed file back to its original location. It refuses to
+// overwrite a file that has since been created at that location.
+func (b *Bin) Restore(item *Item) error {
+	if _, err := os.Stat(item.OriginalPath); err == nil {
+		return fmt.Errorf("restoring %s: file already exists at %s", item.Filename(), item.OriginalPath)
+	}
+
+	if err := fsutil.MoveFile(filepath.Join(b.dir, item.Name), item.OriginalPath); err != nil {
+		return fmt.Errorf("restoring %s: %w", item.Filename(), err)
+	}
+
+	b.remove(item)
+	return b.save()
+}
+
+// Expired returns the items that have been in the trash longer than maxAge.
+func (b *Bin) Expired(maxAge time.Duration) []*Item {
+	cutoff := time.Now().Add(-maxAge)
+
+	var expired []*Item
+	for _, item := range b.Items {
+		if item.TrashedAt.Before(cutoff) {
+			expired = append(expired, item)
+		}
+	}
+	return expired
+}
+
+// Purge permanently deletes the items that have been in the trash longer than
+// maxAge, returning the purged items.
+func (b *Bin) Purge(maxAge time.Duration) ([]*Item, error) {
+	expired := b.Expired(maxAge)
+	if len(expired) == 0 {
+		return nil, nil
+	}
+
+	var purged []*Item
+	for _, item := range expired {
+		err := os.Remove(filepath.Join(b.dir, item.Name))
+		if err != nil && !os.IsNotExist(err) {
+			return purged, fmt.Errorf("purging %s: %w", item.Name, err)
+		}
+		b.remove(item)
+		purged = append(purged, item)
+	}
+
+	return purged, b.save()
+}
+
+// PurgeOldest permanently deletes the item that has been in the trash the
+// longest, returning it, or nil if the trash is empty.
+func (b *Bin) PurgeOldest() (*Item, error) {
+	if len(b.Items) == 0 {
+		return nil, nil
+	}
+
+	oldest := slices.MinFunc(b.Items, func(x, y *Item) int {
+		return x.TrashedAt.Compare(y.TrashedAt)
+	})
+
+	err := os.Remove(filepath.Join(b.dir, oldest.Name))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("purging %s: %w", oldest.Name, err)
+	}
+	b.remove(oldest)
+
+	return oldest, b.save()
+}
+
+// remove drops an item from the manifest.
+func (b *Bin) remove(item *Item) {
+	b.Items = slices.DeleteFunc(b.Items, func(i *Item) bool { return i == item })
+}
+
+// save writes the manifest to disk atomically.
+func (b *Bin) save() error {
+	if err := os.MkdirAll(b.dir, 0o750); err != nil {
+		return fmt.Errorf("creating trash directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding trash manifest: %w", err)
+	}
+
+	path := filepath.Join(b.dir, manifestName)
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return fmt.Errorf("writing trash manifest: %w", err)
+	}
+	return os.Rename(tmpPath, path)
+}
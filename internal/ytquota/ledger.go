@@ -0,0 +1,160 @@
+// Package ytquota keeps a local ledger of estimated YouTube Data API quota usage.
+//
+// The YouTube Data API grants each project a daily budget of quota units that
+// resets at midnight Pacific Time. The API offers no way to query the remaining
+// budget, so usage is estimated locally from the documented cost of each call:
+// https://developers.google.com/youtube/v3/determine_quota_cost
+package ytquota
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"google.golang.org/api/googleapi"
+)
+
+// Estimated quota costs for each API call used by herosync.
+const (
+	CostChannelsList = 1
+	CostSearchList   = 100
+	CostVideosList   = 1
+	CostVideosInsert = 1600
+)
+
+// ErrBudgetExceeded is returned when a call would exceed the day's budget.
+var ErrBudgetExceeded = errors.New("daily YouTube API quota budget exceeded")
+
+// Ledger tracks the quota units spent during the current quota day.
+type Ledger struct {
+	Day       string         `json:"day"`       // Quota day (Pacific Time), format: YYYY-MM-DD
+	Used      int            `json:"used"`      // Estimated units spent today
+	Exhausted bool           `json:"exhausted"` // Whether the API reported the quota as exceeded
+	Calls     map[string]int `json:"calls"`     // Number of calls made today, by operation
+
+	path  string
+	limit int
+}
+
+// Load reads the ledger at path, starting a fresh one if the file doesn't exist
+// or was recorded on a previous quota day.
+func Load(path string, limit int) (*Ledger, error) {
+	l := &Ledger{path: path, limit: limit}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("reading quota ledger: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, l); err != nil {
+			return nil, fmt.Errorf("decoding quota ledger: %w", err)
+		}
+	}
+
+	if today := quotaDay(time.Now()); l.Day != today {
+		l.Day = today
+		l.Used = 0
+		l.Exhausted = false
+		l.Calls = nil
+	}
+	if l.Calls == nil {
+		l.Calls = make(map[string]int)
+	}
+
+	return l, nil
+}
+
+// Limit returns the configured daily budget.
+func (l *Ledger) Limit() int {
+	return l.limit
+}
+
+// Remaining returns the estimated number of units left in today's budget.
+func (l *Ledger) Remaining() int {
+	if l.Exhausted {
+		return 0
+	}
+	return max(l.limit-l.Used, 0)
+}
+
+// CanSpend reports whether the given number of units fits in today's budget.
+func (l *Ledger) CanSpend(units int) bool {
+	return units <= l.Remaining()
+}
+
+// Spend records the cost of an API call before it is made, persisting the
+// ledger. It returns ErrBudgetExceeded without recording anything if the call
+// would exceed today's budget.
+func (l *Ledger) Spend(operation string, units int) error {
+	if !l.CanSpend(units) {
+		return fmt.Errorf("%s needs %d units, %d remaining: %w", operation, units, l.Remaining(), ErrBudgetExceeded)
+	}
+	l.Used += units
+	l.Calls[operation]++
+	return l.save()
+}
+
+// MarkExhausted records that the API rejected a call for lack of quota, so no
+// further calls are attempted until the quota resets.
+func (l *Ledger) MarkExhausted() error {
+	l.Exhausted = true
+	return l.save()
+}
+
+// ResetsAt returns the time at which the current quota day ends.
+func (l *Ledger) ResetsAt() time.Time {
+	now := time.Now().In(pacific())
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return midnight.AddDate(0, 0, 1)
+}
+
+// save writes the ledger to disk atomically.
+func (l *Ledger) save() error {
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o750); err != nil {
+		return fmt.Errorf("creating quota ledger directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding quota ledger: %w", err)
+	}
+
+	tmpPath := l.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return fmt.Errorf("writing quota ledger: %w", err)
+	}
+	return os.Rename(tmpPath, l.path)
+}
+
+// IsQuotaExceeded reports whether err is an API error caused by running out of quota.
+func IsQuotaExceeded(err error) bool {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	for _, item := range apiErr.Errors {
+		switch item.Reason {
+		case "quotaExceeded", "dailyLimitExceeded", "uploadLimitExceeded":
+			return true
+		}
+	}
+	return false
+}
+
+// quotaDay returns the quota day that t falls within.
+func quotaDay(t time.Time) string {
+	return t.In(pacific()).Format(time.DateOnly)
+}
+
+// pacific returns the Pacific Time location used for quota resets, falling
+// back to a fixed PST offset if the time zone database is unavailable.
+func pacific() *time.Location {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		return time.FixedZone("PST", -8*60*60)
+	}
+	return loc
+}